@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"fmt"
+	"math"
 	"strings"
 )
 
@@ -32,6 +33,26 @@ const (
 	AclOperationIdempotentWrite
 )
 
+// authorizedOperationsOmitted is the value of the authorized operations in a
+// MetadataResponse when they were not requested.
+const authorizedOperationsOmitted int32 = math.MinInt32
+
+// authorizedOperations returns the operations whose bit is set in bitfield, as
+// used by responses listing the operations a client is authorized to perform,
+// or nil if bitfield is authorizedOperationsOmitted.
+func authorizedOperations(bitfield int32) []AclOperation {
+	if bitfield == authorizedOperationsOmitted {
+		return nil
+	}
+	ops := make([]AclOperation, 0)
+	for op := AclOperationUnknown; op < 32; op++ {
+		if bitfield&(1<<uint(op)) != 0 {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
 func (a *AclOperation) String() string {
 	mapping := map[AclOperation]string{
 		AclOperationUnknown:         "Unknown",
@@ -23,7 +23,9 @@ type ClusterAdmin interface {
 	// List the topics available in the cluster with the default options.
 	ListTopics() (map[string]TopicDetail, error)
 
-	// Describe some topics in the cluster.
+	// Describe some topics in the cluster. From Kafka 2.3, the operations the
+	// client is authorized to perform on each topic are included, see
+	// TopicMetadata.AuthorizedOperations.
 	DescribeTopics(topics []string) (metadata []*TopicMetadata, err error)
 
 	// Delete a topic. It may take several seconds after the DeleteTopic to returns success
@@ -95,12 +97,23 @@ type ClusterAdmin interface {
 	// List the consumer group offsets available in the cluster.
 	ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error)
 
+	// Describe the lag of the given consumer groups. For each group and partition,
+	// the committed offset is compared to the log-end offset, and the member owning
+	// the partition is decoded from the group's member assignments. Before Kafka
+	// 0.10.2, only the assigned partitions are described, and groups not using
+	// the "consumer" protocol type fail.
+	DescribeConsumerGroupLag(groups []string) (map[string]*ConsumerGroupLag, error)
+
 	// Delete a consumer group.
 	DeleteConsumerGroup(group string) error
 
 	// Get information about the nodes in the cluster
 	DescribeCluster() (brokers []*Broker, controllerID int32, err error)
 
+	// Get the operations the client is authorized to perform on the cluster.
+	// This operation is supported by brokers with version 2.3.0.0 or higher.
+	DescribeClusterAuthorizedOperations() ([]AclOperation, error)
+
 	// Get information about all log directories on the given set of brokers
 	DescribeLogDirs(brokers []int32) (map[int32][]DescribeLogDirsResponseDirMetadata, error)
 
@@ -183,7 +196,7 @@ func (ca *clusterAdmin) retryOnError(retriable func(error) bool, fn func() error
 		if err == nil || !retriable(err) {
 			return err
 		}
-		Logger.Printf(
+		ca.conf.logger().Debugf(
 			"admin/request retrying after %dms... (%d attempts remaining)\n",
 			ca.conf.Admin.Retry.Backoff/time.Millisecond, ca.conf.Admin.Retry.Max-attempt)
 		time.Sleep(ca.conf.Admin.Retry.Backoff)
@@ -255,7 +268,10 @@ func (ca *clusterAdmin) DescribeTopics(topics []string) (metadata []*TopicMetada
 		AllowAutoTopicCreation: false,
 	}
 
-	if ca.conf.Version.IsAtLeast(V1_0_0_0) {
+	if ca.conf.Version.IsAtLeast(V2_3_0_0) {
+		request.Version = 8
+		request.IncludeTopicAuthorizedOperations = true
+	} else if ca.conf.Version.IsAtLeast(V1_0_0_0) {
 		request.Version = 5
 	} else if ca.conf.Version.IsAtLeast(V0_11_0_0) {
 		request.Version = 4
@@ -275,7 +291,7 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 	}
 
 	request := &MetadataRequest{
-		Topics: []string{},
+		NoTopics: true,
 	}
 
 	if ca.conf.Version.IsAtLeast(V0_10_0_0) {
@@ -290,6 +306,30 @@ func (ca *clusterAdmin) DescribeCluster() (brokers []*Broker, controllerID int32
 	return response.Brokers, response.ControllerID, nil
 }
 
+func (ca *clusterAdmin) DescribeClusterAuthorizedOperations() ([]AclOperation, error) {
+	if !ca.conf.Version.IsAtLeast(V2_3_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	controller, err := ca.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	request := &MetadataRequest{
+		Version:                            8,
+		NoTopics:                           true,
+		IncludeClusterAuthorizedOperations: true,
+	}
+
+	response, err := controller.GetMetadata(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.AuthorizedOperations(), nil
+}
+
 func (ca *clusterAdmin) findBroker(id int32) (*Broker, error) {
 	brokers := ca.client.Brokers()
 	for _, b := range brokers {
@@ -865,6 +905,153 @@ func (ca *clusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions m
 	return coordinator.FetchOffset(request)
 }
 
+// ConsumerGroupLag holds the lag of a consumer group, by topic and partition.
+type ConsumerGroupLag struct {
+	Group      string
+	State      string
+	Partitions map[string]map[int32]*PartitionLag
+}
+
+// PartitionLag holds the lag of a consumer group on a single partition.
+type PartitionLag struct {
+	// CommittedOffset is the offset committed by the group, or -1 if none.
+	CommittedOffset int64
+	// LogEndOffset is the offset of the next message to be produced to the
+	// partition, or -1 if it could not be retrieved.
+	LogEndOffset int64
+	// Lag is the number of messages between CommittedOffset and LogEndOffset,
+	// or -1 if either of them is unknown. It is 0 when CommittedOffset is past
+	// LogEndOffset, e.g. after the log was truncated.
+	Lag int64
+	// MemberID, ClientID and ClientHost identify the group member the partition
+	// is assigned to. They are empty if the partition is not assigned.
+	MemberID   string
+	ClientID   string
+	ClientHost string
+	// Err is the error encountered while retrieving the offsets, if any.
+	Err error
+}
+
+func (ca *clusterAdmin) DescribeConsumerGroupLag(groups []string) (map[string]*ConsumerGroupLag, error) {
+	descriptions, err := ca.DescribeConsumerGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*ConsumerGroupLag, len(descriptions))
+	logEndOffsets := make(map[string]map[int32]int64)
+	for _, description := range descriptions {
+		if description.Err != ErrNoError {
+			return nil, description.Err
+		}
+
+		groupLag := &ConsumerGroupLag{
+			Group:      description.GroupId,
+			State:      description.State,
+			Partitions: make(map[string]map[int32]*PartitionLag),
+		}
+		partitionLag := func(topic string, partition int32) *PartitionLag {
+			if groupLag.Partitions[topic] == nil {
+				groupLag.Partitions[topic] = make(map[int32]*PartitionLag)
+			}
+			lag := groupLag.Partitions[topic][partition]
+			if lag == nil {
+				lag = &PartitionLag{CommittedOffset: -1, LogEndOffset: -1, Lag: -1}
+				groupLag.Partitions[topic][partition] = lag
+			}
+			if logEndOffsets[topic] == nil {
+				logEndOffsets[topic] = make(map[int32]int64)
+			}
+			logEndOffsets[topic][partition] = OffsetNewest
+			return lag
+		}
+
+		var assigned map[string][]int32
+		if description.ProtocolType == "consumer" {
+			assigned = make(map[string][]int32)
+			for memberID, member := range description.Members {
+				if len(member.MemberAssignment) == 0 {
+					continue
+				}
+				assignment, err := member.GetMemberAssignment()
+				if err != nil {
+					return nil, err
+				}
+				for topic, partitions := range assignment.Topics {
+					for _, partition := range partitions {
+						lag := partitionLag(topic, partition)
+						lag.MemberID = memberID
+						lag.ClientID = member.ClientId
+						lag.ClientHost = member.ClientHost
+					}
+					assigned[topic] = append(assigned[topic], partitions...)
+				}
+			}
+		}
+
+		// fetch all committed offsets when supported, otherwise only the
+		// ones of the assigned partitions
+		if ca.conf.Version.IsAtLeast(V0_10_2_0) {
+			assigned = nil
+		} else if assigned == nil {
+			return nil, fmt.Errorf("kafka: the partitions of group %s with protocol type %q cannot be listed before Kafka 0.10.2",
+				description.GroupId, description.ProtocolType)
+		}
+		offsets, err := ca.ListConsumerGroupOffsets(description.GroupId, assigned)
+		if err != nil {
+			return nil, err
+		}
+		if offsets.Err != ErrNoError {
+			return nil, offsets.Err
+		}
+		for topic, blocks := range offsets.Blocks {
+			for partition, block := range blocks {
+				if block.Err != ErrNoError {
+					partitionLag(topic, partition).Err = block.Err
+					continue
+				}
+				if block.Offset < 0 && groupLag.Partitions[topic][partition] == nil {
+					// neither committed nor assigned
+					continue
+				}
+				partitionLag(topic, partition).CommittedOffset = block.Offset
+			}
+		}
+
+		result[description.GroupId] = groupLag
+	}
+
+	endOffsets, err := ca.client.GetOffsets(logEndOffsets)
+	if err != nil {
+		return nil, err
+	}
+	for _, groupLag := range result {
+		for topic, partitions := range groupLag.Partitions {
+			for partition, lag := range partitions {
+				endOffset := endOffsets[topic][partition]
+				if endOffset == nil {
+					continue
+				}
+				if endOffset.Err != nil {
+					if lag.Err == nil {
+						lag.Err = endOffset.Err
+					}
+					continue
+				}
+				lag.LogEndOffset = endOffset.Offset
+				if lag.CommittedOffset >= 0 && lag.LogEndOffset >= 0 {
+					lag.Lag = lag.LogEndOffset - lag.CommittedOffset
+					if lag.Lag < 0 {
+						lag.Lag = 0
+					}
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
 func (ca *clusterAdmin) DeleteConsumerGroup(group string) error {
 	coordinator, err := ca.client.Coordinator(group)
 	if err != nil {
@@ -904,7 +1091,7 @@ func (ca *clusterAdmin) DescribeLogDirs(brokerIds []int32) (allLogDirs map[int32
 		wg.Add(1)
 		broker, err := ca.findBroker(b)
 		if err != nil {
-			Logger.Printf("Unable to find broker with ID = %v\n", b)
+			ca.conf.logger(LogField{LogFieldBroker, b}).Warnf("Unable to find broker with ID = %v\n", b)
 			continue
 		}
 		go func(b *Broker, conf *Config) {
@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -1054,6 +1055,15 @@ func TestDescribeTopic(t *testing.T) {
 		t.Fatalf("Incorrect topic name: %v", topics[0].Name)
 	}
 
+	// no topics describes all of them
+	topics, err = admin.DescribeTopics([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || topics[0].Name != "my_topic" {
+		t.Fatalf("Expected all the topics, got %v", topics)
+	}
+
 	err = admin.Close()
 	if err != nil {
 		t.Fatal(err)
@@ -1098,6 +1108,87 @@ func TestDescribeTopicWithVersion0_11(t *testing.T) {
 	}
 }
 
+func TestDescribeTopicWithVersion2_3(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetTopicAuthorizedOperations("my_topic", 1<<AclOperationRead|1<<AclOperationDescribe),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	topics, err := admin.DescribeTopics([]string{"my_topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(topics) != 1 {
+		t.Fatalf("Expected 1 result, got %v", len(topics))
+	}
+
+	ops := topics[0].AuthorizedOperations()
+	if !reflect.DeepEqual(ops, []AclOperation{AclOperationRead, AclOperationDescribe}) {
+		t.Fatalf("Incorrect authorized operations: %v", ops)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDescribeClusterAuthorizedOperations(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetClusterAuthorizedOperations(1 << AclOperationDescribe),
+	})
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := admin.DescribeClusterAuthorizedOperations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ops, []AclOperation{AclOperationDescribe}) {
+		t.Fatalf("Incorrect authorized operations: %v", ops)
+	}
+
+	// the metadata of the topics is not requested
+	history := seedBroker.History()
+	request, ok := history[len(history)-1].Request.(*MetadataRequest)
+	if !ok || !request.NoTopics {
+		t.Errorf("Expected a metadata request for no topics, got %+v", history[len(history)-1].Request)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDescribeConsumerGroup(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
@@ -1297,6 +1388,127 @@ func TestListConsumerGroupOffsets(t *testing.T) {
 	}
 }
 
+func TestDescribeConsumerGroupLag(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+	topic := "my-topic"
+
+	assignment, err := encode(&ConsumerGroupMemberAssignment{
+		Topics: map[string][]int32{topic: {0}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).AddGroupDescription(group, &GroupDescription{
+			GroupId:      group,
+			State:        "Stable",
+			ProtocolType: "consumer",
+			Members: map[string]*GroupMemberDescription{
+				"member-1": {ClientId: "client-1", ClientHost: "/127.0.0.1", MemberAssignment: assignment},
+			},
+		}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset(group, topic, 0, 90, "", ErrNoError).
+			SetOffset(group, topic, 1, 40, "", ErrNoError).
+			SetOffset(group, topic, 2, 70, "", ErrNoError).
+			SetError(ErrNoError),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset(topic, 0, OffsetNewest, 100).
+			SetOffset(topic, 1, OffsetNewest, 50).
+			SetOffset(topic, 2, OffsetNewest, 60),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader(topic, 0, seedBroker.BrokerID()).
+			SetLeader(topic, 1, seedBroker.BrokerID()).
+			SetLeader(topic, 2, seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := admin.DescribeConsumerGroupLag([]string{group})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groupLag := result[group]
+	if groupLag == nil || groupLag.State != "Stable" {
+		t.Fatalf("Expected a stable group lag, got %+v", groupLag)
+	}
+
+	lag := groupLag.Partitions[topic][0]
+	if lag == nil || lag.CommittedOffset != 90 || lag.LogEndOffset != 100 || lag.Lag != 10 || lag.Err != nil {
+		t.Fatalf("Unexpected lag for partition 0: %+v", lag)
+	}
+	if lag.MemberID != "member-1" || lag.ClientID != "client-1" || lag.ClientHost != "/127.0.0.1" {
+		t.Fatalf("Unexpected owner for partition 0: %+v", lag)
+	}
+
+	lag = groupLag.Partitions[topic][1]
+	if lag == nil || lag.Lag != 10 || lag.MemberID != "" {
+		t.Fatalf("Unexpected lag for unassigned partition 1: %+v", lag)
+	}
+
+	lag = groupLag.Partitions[topic][2]
+	if lag == nil || lag.CommittedOffset != 70 || lag.LogEndOffset != 60 || lag.Lag != 0 {
+		t.Fatalf("Unexpected lag for partition 2 committed past its log end: %+v", lag)
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDescribeConsumerGroupLagOtherProtocolType(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	group := "my-group"
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"DescribeGroupsRequest": NewMockDescribeGroupsResponse(t).AddGroupDescription(group, &GroupDescription{
+			GroupId:      group,
+			State:        "Stable",
+			ProtocolType: "connect",
+		}),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).SetError(ErrNoError),
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).SetCoordinator(CoordinatorGroup, group, seedBroker),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_10_1_0
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.DescribeConsumerGroupLag([]string{group}); err == nil {
+		t.Error("Expected an error describing a connect group before Kafka 0.10.2")
+	}
+
+	err = admin.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestDeleteConsumerGroup(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	defer seedBroker.Close()
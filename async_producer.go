@@ -100,7 +100,7 @@ func newTransactionManager(conf *Config, client Client) (*transactionManager, er
 		txnmgr.sequenceNumbers = make(map[string]int32)
 		txnmgr.mutex = sync.Mutex{}
 
-		Logger.Printf("Obtained a ProducerId: %d and ProducerEpoch: %d\n", txnmgr.producerID, txnmgr.producerEpoch)
+		conf.logger().Infof("Obtained a ProducerId: %d and ProducerEpoch: %d\n", txnmgr.producerID, txnmgr.producerEpoch)
 	}
 
 	return txnmgr, nil
@@ -118,6 +118,13 @@ type asyncProducer struct {
 	brokerRefs map[*brokerProducer]int
 	brokerLock sync.Mutex
 
+	// when Producer.Buffer.Memory is set, messages sent on Input() go
+	// through bufferedInput to reserve their share of the buffer before
+	// reaching the dispatcher
+	buffer        *producerBuffer
+	bufferedInput chan *ProducerMessage
+	bufferDone    chan none
+
 	txnmgr *transactionManager
 }
 
@@ -162,6 +169,13 @@ func newAsyncProducer(client Client) (AsyncProducer, error) {
 		txnmgr:     txnmgr,
 	}
 
+	if p.conf.Producer.Buffer.Memory > 0 {
+		p.buffer = newProducerBuffer(p.conf.Producer.Buffer.Memory)
+		p.bufferedInput = make(chan *ProducerMessage)
+		p.bufferDone = make(chan none)
+		go withRecover(p.bufferAdmitter)
+	}
+
 	// launch our singleton dispatchers
 	go withRecover(p.dispatcher)
 	go withRecover(p.retryHandler)
@@ -221,10 +235,12 @@ type ProducerMessage struct {
 
 	retries        int
 	flags          flagSet
-	expectation    chan *ProducerError
+	expectation    chan *ProducerError // set by the SyncProducer, bypasses the Successes and Errors channels
 	sequenceNumber int32
 	producerEpoch  int16
 	hasSequence    bool
+	bufferedBytes  int
+	expiresAt      time.Time
 }
 
 const producerMessageOverhead = 26 // the metadata overhead of CRC, flags, etc.
@@ -251,6 +267,7 @@ func (m *ProducerMessage) byteSize(version int) int {
 func (m *ProducerMessage) clear() {
 	m.flags = 0
 	m.retries = 0
+	m.expiresAt = time.Time{}
 	m.sequenceNumber = 0
 	m.producerEpoch = 0
 	m.hasSequence = false
@@ -289,6 +306,9 @@ func (p *asyncProducer) Successes() <-chan *ProducerMessage {
 }
 
 func (p *asyncProducer) Input() chan<- *ProducerMessage {
+	if p.bufferedInput != nil {
+		return p.bufferedInput
+	}
 	return p.input
 }
 
@@ -329,7 +349,7 @@ func (p *asyncProducer) dispatcher() {
 
 	for msg := range p.input {
 		if msg == nil {
-			Logger.Println("Something tried to send a nil message, it was ignored.")
+			p.conf.logger().Warnf("Something tried to send a nil message, it was ignored.")
 			continue
 		}
 
@@ -339,27 +359,24 @@ func (p *asyncProducer) dispatcher() {
 			continue
 		} else if msg.retries == 0 {
 			if shuttingDown {
-				// we can't just call returnError here because that decrements the wait group,
-				// which hasn't been incremented yet for this message, and shouldn't be
-				pErr := &ProducerError{Msg: msg, Err: ErrShuttingDown}
-				if p.conf.Producer.Return.Errors {
-					p.errors <- pErr
-				} else {
-					Logger.Println(pErr)
-				}
+				p.returnUncountedError(msg, ErrShuttingDown)
 				continue
 			}
 			p.inFlight.Add(1)
+			if p.conf.Producer.DeliveryTimeout > 0 {
+				msg.expiresAt = time.Now().Add(p.conf.Producer.DeliveryTimeout)
+			}
+		} else if p.deliveryExpired(msg) {
+			p.returnError(msg, ErrDeliveryTimeout)
+			continue
 		}
 
 		for _, interceptor := range p.conf.Producer.Interceptors {
 			msg.safelyApplyInterceptor(interceptor)
 		}
 
-		version := 1
-		if p.conf.Version.IsAtLeast(V0_11_0_0) {
-			version = 2
-		} else if msg.Headers != nil {
+		version := p.messageVersion()
+		if version < 2 && msg.Headers != nil {
 			p.returnError(msg, ConfigurationError("Producing headers requires Kafka at least v0.11"))
 			continue
 		}
@@ -382,6 +399,31 @@ func (p *asyncProducer) dispatcher() {
 	}
 }
 
+// singleton
+// reserves buffer memory for the messages sent on Input() before handing them
+// to the dispatcher, blocking the sender while the buffer is exhausted
+func (p *asyncProducer) bufferAdmitter() {
+	for msg := range p.bufferedInput {
+		if msg != nil && msg.flags&shutdown == 0 {
+			size, err := p.buffer.acquire(msg.byteSize(p.messageVersion()), p.conf.Producer.Buffer.MaxBlock)
+			if err != nil {
+				p.returnUncountedError(msg, err)
+				continue
+			}
+			msg.bufferedBytes = size
+		}
+		p.input <- msg
+	}
+	close(p.bufferDone)
+}
+
+func (p *asyncProducer) messageVersion() int {
+	if p.conf.Version.IsAtLeast(V0_11_0_0) {
+		return 2
+	}
+	return 1
+}
+
 // one per topic
 // partitions messages, then dispatches them by partition
 type topicProducer struct {
@@ -478,6 +520,7 @@ func (tp *topicProducer) partitionMessage(msg *ProducerMessage) error {
 // also responsible for maintaining message order during retries
 type partitionProducer struct {
 	parent    *asyncProducer
+	logger    fieldLogger
 	topic     string
 	partition int32
 	input     <-chan *ProducerMessage
@@ -503,6 +546,7 @@ func (p *asyncProducer) newPartitionProducer(topic string, partition int32) chan
 	input := make(chan *ProducerMessage, p.conf.ChannelBufferSize)
 	pp := &partitionProducer{
 		parent:    p,
+		logger:    p.conf.logger(LogField{LogFieldTopic, topic}, LogField{LogFieldPartition, partition}),
 		topic:     topic,
 		partition: partition,
 		input:     input,
@@ -544,11 +588,16 @@ func (pp *partitionProducer) dispatch() {
 	}()
 
 	for msg := range pp.input {
+		if pp.parent.deliveryExpired(msg) {
+			pp.parent.returnError(msg, ErrDeliveryTimeout)
+			continue
+		}
+
 		if pp.brokerProducer != nil && pp.brokerProducer.abandoned != nil {
 			select {
 			case <-pp.brokerProducer.abandoned:
 				// a message on the abandoned channel means that our current broker selection is out of date
-				Logger.Printf("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+				pp.logger.Infof("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
 				pp.parent.unrefBrokerProducer(pp.leader, pp.brokerProducer)
 				pp.brokerProducer = nil
 				time.Sleep(pp.parent.conf.Producer.Retry.Backoff)
@@ -591,7 +640,7 @@ func (pp *partitionProducer) dispatch() {
 				pp.backoff(msg.retries)
 				continue
 			}
-			Logger.Printf("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+			pp.logger.Infof("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
 		}
 
 		// Now that we know we have a broker to actually try and send this message to, generate the sequence
@@ -608,7 +657,7 @@ func (pp *partitionProducer) dispatch() {
 }
 
 func (pp *partitionProducer) newHighWatermark(hwm int) {
-	Logger.Printf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, hwm)
+	pp.logger.Debugf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, hwm)
 	pp.highWatermark = hwm
 
 	// send off a fin so that we know when everything "in between" has made it
@@ -618,13 +667,13 @@ func (pp *partitionProducer) newHighWatermark(hwm int) {
 	pp.brokerProducer.input <- &ProducerMessage{Topic: pp.topic, Partition: pp.partition, flags: fin, retries: pp.highWatermark - 1}
 
 	// a new HWM means that our current broker selection is out of date
-	Logger.Printf("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+	pp.logger.Infof("producer/leader/%s/%d abandoning broker %d\n", pp.topic, pp.partition, pp.leader.ID())
 	pp.parent.unrefBrokerProducer(pp.leader, pp.brokerProducer)
 	pp.brokerProducer = nil
 }
 
 func (pp *partitionProducer) flushRetryBuffers() {
-	Logger.Printf("producer/leader/%s/%d state change to [flushing-%d]\n", pp.topic, pp.partition, pp.highWatermark)
+	pp.logger.Debugf("producer/leader/%s/%d state change to [flushing-%d]\n", pp.topic, pp.partition, pp.highWatermark)
 	for {
 		pp.highWatermark--
 
@@ -633,7 +682,7 @@ func (pp *partitionProducer) flushRetryBuffers() {
 				pp.parent.returnErrors(pp.retryState[pp.highWatermark].buf, err)
 				goto flushDone
 			}
-			Logger.Printf("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
+			pp.logger.Infof("producer/leader/%s/%d selected broker %d\n", pp.topic, pp.partition, pp.leader.ID())
 		}
 
 		for _, msg := range pp.retryState[pp.highWatermark].buf {
@@ -643,10 +692,10 @@ func (pp *partitionProducer) flushRetryBuffers() {
 	flushDone:
 		pp.retryState[pp.highWatermark].buf = nil
 		if pp.retryState[pp.highWatermark].expectChaser {
-			Logger.Printf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, pp.highWatermark)
+			pp.logger.Debugf("producer/leader/%s/%d state change to [retrying-%d]\n", pp.topic, pp.partition, pp.highWatermark)
 			break
 		} else if pp.highWatermark == 0 {
-			Logger.Printf("producer/leader/%s/%d state change to [normal]\n", pp.topic, pp.partition)
+			pp.logger.Debugf("producer/leader/%s/%d state change to [normal]\n", pp.topic, pp.partition)
 			break
 		}
 	}
@@ -681,6 +730,7 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) *brokerProducer {
 	bp := &brokerProducer{
 		parent:         p,
 		broker:         broker,
+		logger:         p.conf.logger(LogField{LogFieldBroker, broker.ID()}),
 		input:          input,
 		output:         bridge,
 		responses:      responses,
@@ -693,6 +743,13 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) *brokerProducer {
 	// minimal bridge to make the network response `select`able
 	go withRecover(func() {
 		for set := range bridge {
+			if p.conf.Producer.DeliveryTimeout > 0 {
+				p.expireMessages(set)
+				if set.empty() {
+					continue
+				}
+			}
+
 			request := set.buildRequest()
 
 			response, err := broker.Produce(request)
@@ -724,6 +781,7 @@ type brokerProducerResponse struct {
 type brokerProducer struct {
 	parent *asyncProducer
 	broker *Broker
+	logger fieldLogger
 
 	input     chan *ProducerMessage
 	output    chan<- *produceSet
@@ -741,13 +799,13 @@ type brokerProducer struct {
 
 func (bp *brokerProducer) run() {
 	var output chan<- *produceSet
-	Logger.Printf("producer/broker/%d starting up\n", bp.broker.ID())
+	bp.logger.Debugf("producer/broker/%d starting up\n", bp.broker.ID())
 
 	for {
 		select {
 		case msg, ok := <-bp.input:
 			if !ok {
-				Logger.Printf("producer/broker/%d input chan closed\n", bp.broker.ID())
+				bp.logger.Debugf("producer/broker/%d input chan closed\n", bp.broker.ID())
 				bp.shutdown()
 				return
 			}
@@ -757,7 +815,7 @@ func (bp *brokerProducer) run() {
 			}
 
 			if msg.flags&syn == syn {
-				Logger.Printf("producer/broker/%d state change to [open] on %s/%d\n",
+				bp.logger.Debugf("producer/broker/%d state change to [open] on %s/%d\n",
 					bp.broker.ID(), msg.Topic, msg.Partition)
 				if bp.currentRetries[msg.Topic] == nil {
 					bp.currentRetries[msg.Topic] = make(map[int32]error)
@@ -767,13 +825,18 @@ func (bp *brokerProducer) run() {
 				continue
 			}
 
+			if bp.parent.deliveryExpired(msg) {
+				bp.parent.returnError(msg, ErrDeliveryTimeout)
+				continue
+			}
+
 			if reason := bp.needsRetry(msg); reason != nil {
 				bp.parent.retryMessage(msg, reason)
 
 				if bp.closing == nil && msg.flags&fin == fin {
 					// we were retrying this partition but we can start processing again
 					delete(bp.currentRetries[msg.Topic], msg.Partition)
-					Logger.Printf("producer/broker/%d state change to [closed] on %s/%d\n",
+					bp.logger.Debugf("producer/broker/%d state change to [closed] on %s/%d\n",
 						bp.broker.ID(), msg.Topic, msg.Partition)
 				}
 
@@ -781,7 +844,7 @@ func (bp *brokerProducer) run() {
 			}
 
 			if bp.buffer.wouldOverflow(msg) {
-				Logger.Printf("producer/broker/%d maximum request accumulated, waiting for space\n", bp.broker.ID())
+				bp.logger.Debugf("producer/broker/%d maximum request accumulated, waiting for space\n", bp.broker.ID())
 				if err := bp.waitForSpace(msg, false); err != nil {
 					bp.parent.retryMessage(msg, err)
 					continue
@@ -790,7 +853,7 @@ func (bp *brokerProducer) run() {
 
 			if bp.parent.txnmgr.producerID != noProducerID && bp.buffer.producerEpoch != msg.producerEpoch {
 				// The epoch was reset, need to roll the buffer over
-				Logger.Printf("producer/broker/%d detected epoch rollover, waiting for new buffer\n", bp.broker.ID())
+				bp.logger.Debugf("producer/broker/%d detected epoch rollover, waiting for new buffer\n", bp.broker.ID())
 				if err := bp.waitForSpace(msg, true); err != nil {
 					bp.parent.retryMessage(msg, err)
 					continue
@@ -813,7 +876,7 @@ func (bp *brokerProducer) run() {
 				bp.handleResponse(response)
 			}
 		case <-bp.stopchan:
-			Logger.Printf(
+			bp.logger.Debugf(
 				"producer/broker/%d run loop asked to stop\n", bp.broker.ID())
 			return
 		}
@@ -840,7 +903,7 @@ func (bp *brokerProducer) shutdown() {
 		bp.handleResponse(response)
 	}
 	close(bp.stopchan)
-	Logger.Printf("producer/broker/%d shut down\n", bp.broker.ID())
+	bp.logger.Debugf("producer/broker/%d shut down\n", bp.broker.ID())
 }
 
 func (bp *brokerProducer) needsRetry(msg *ProducerMessage) error {
@@ -941,7 +1004,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 		if bp.parent.conf.Producer.Idempotent {
 			err := bp.parent.client.RefreshMetadata(retryTopics...)
 			if err != nil {
-				Logger.Printf("Failed refreshing metadata because of %v\n", err)
+				bp.logger.Warnf("Failed refreshing metadata because of %v\n", err)
 			}
 		}
 
@@ -955,7 +1018,7 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 			switch block.Err {
 			case ErrInvalidMessage, ErrUnknownTopicOrPartition, ErrLeaderNotAvailable, ErrNotLeaderForPartition,
 				ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
-				Logger.Printf("producer/broker/%d state change to [retrying] on %s/%d because %v\n",
+				bp.logger.Warnf("producer/broker/%d state change to [retrying] on %s/%d because %v\n",
 					bp.broker.ID(), topic, partition, block.Err)
 				if bp.currentRetries[topic] == nil {
 					bp.currentRetries[topic] = make(map[int32]error)
@@ -974,24 +1037,32 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 }
 
 func (p *asyncProducer) retryBatch(topic string, partition int32, pSet *partitionSet, kerr KError) {
-	Logger.Printf("Retrying batch for %v-%d because of %s\n", topic, partition, kerr)
+	logger := p.conf.logger(LogField{LogFieldTopic, topic}, LogField{LogFieldPartition, partition})
+	logger.Debugf("Retrying batch for %v-%d because of %s\n", topic, partition, kerr)
 	produceSet := newProduceSet(p)
 	produceSet.msgs[topic] = make(map[int32]*partitionSet)
 	produceSet.msgs[topic][partition] = pSet
 	produceSet.bufferBytes += pSet.bufferBytes
 	produceSet.bufferCount += len(pSet.msgs)
+	// the batch keeps its sequence numbers, so it is retried or failed whole
 	for _, msg := range pSet.msgs {
+		if p.deliveryExpired(msg) {
+			p.returnErrors(pSet.msgs, ErrDeliveryTimeout)
+			return
+		}
 		if msg.retries >= p.conf.Producer.Retry.Max {
-			p.returnError(msg, kerr)
+			p.returnErrors(pSet.msgs, kerr)
 			return
 		}
+	}
+	for _, msg := range pSet.msgs {
 		msg.retries++
 	}
 
 	// it's expected that a metadata refresh has been requested prior to calling retryBatch
 	leader, err := p.client.Leader(topic, partition)
 	if err != nil {
-		Logger.Printf("Failed retrying batch for %v-%d because of %v while looking up for new leader\n", topic, partition, err)
+		logger.Errorf("Failed retrying batch for %v-%d because of %v while looking up for new leader\n", topic, partition, err)
 		for _, msg := range pSet.msgs {
 			p.returnError(msg, kerr)
 		}
@@ -1008,7 +1079,7 @@ func (bp *brokerProducer) handleError(sent *produceSet, err error) {
 			bp.parent.returnErrors(pSet.msgs, err)
 		})
 	default:
-		Logger.Printf("producer/broker/%d state change to [closing] because %s\n", bp.broker.ID(), err)
+		bp.logger.Warnf("producer/broker/%d state change to [closing] because %s\n", bp.broker.ID(), err)
 		bp.parent.abandonBrokerConnection(bp.broker)
 		_ = bp.broker.Close()
 		bp.closing = err
@@ -1052,36 +1123,51 @@ func (p *asyncProducer) retryHandler() {
 // utility functions
 
 func (p *asyncProducer) shutdown() {
-	Logger.Println("Producer shutting down.")
+	p.conf.logger().Infof("Producer shutting down.")
 	p.inFlight.Add(1)
-	p.input <- &ProducerMessage{flags: shutdown}
+	// go through Input() so that the messages already sent are dispatched first
+	p.Input() <- &ProducerMessage{flags: shutdown}
 
 	p.inFlight.Wait()
 
 	err := p.client.Close()
 	if err != nil {
-		Logger.Println("producer/shutdown failed to close the embedded client:", err)
+		p.conf.logger().Warnf("producer/shutdown failed to close the embedded client: %s", err)
 	}
 
+	if p.bufferedInput != nil {
+		close(p.bufferedInput)
+		<-p.bufferDone
+	}
 	close(p.input)
 	close(p.retries)
 	close(p.errors)
 	close(p.successes)
 }
 
+func (p *asyncProducer) releaseBuffer(msg *ProducerMessage) {
+	if msg.bufferedBytes > 0 {
+		p.buffer.release(msg.bufferedBytes)
+		msg.bufferedBytes = 0
+	}
+}
+
 func (p *asyncProducer) returnError(msg *ProducerMessage, err error) {
 	// We need to reset the producer ID epoch if we set a sequence number on it, because the broker
 	// will never see a message with this number, so we can never continue the sequence.
 	if msg.hasSequence {
-		Logger.Printf("producer/txnmanager rolling over epoch due to publish failure on %s/%d", msg.Topic, msg.Partition)
+		p.conf.logger(LogField{LogFieldTopic, msg.Topic}, LogField{LogFieldPartition, msg.Partition}).Infof("producer/txnmanager rolling over epoch due to publish failure on %s/%d", msg.Topic, msg.Partition)
 		p.txnmgr.bumpEpoch()
 	}
+	p.releaseBuffer(msg)
 	msg.clear()
 	pErr := &ProducerError{Msg: msg, Err: err}
-	if p.conf.Producer.Return.Errors {
+	if msg.expectation != nil {
+		msg.expectation <- pErr
+	} else if p.conf.Producer.Return.Errors {
 		p.errors <- pErr
 	} else {
-		Logger.Println(pErr)
+		p.conf.logger(LogField{LogFieldTopic, msg.Topic}, LogField{LogFieldPartition, msg.Partition}).Errorf("%s", pErr)
 	}
 	p.inFlight.Done()
 }
@@ -1092,9 +1178,28 @@ func (p *asyncProducer) returnErrors(batch []*ProducerMessage, err error) {
 	}
 }
 
+// returnUncountedError returns an error for a message which was rejected before
+// being accounted for in p.inFlight, so unlike returnError it must not
+// decrement the wait group.
+func (p *asyncProducer) returnUncountedError(msg *ProducerMessage, err error) {
+	p.releaseBuffer(msg)
+	pErr := &ProducerError{Msg: msg, Err: err}
+	if msg.expectation != nil {
+		msg.expectation <- pErr
+	} else if p.conf.Producer.Return.Errors {
+		p.errors <- pErr
+	} else {
+		p.conf.logger(LogField{LogFieldTopic, msg.Topic}).Errorf("%s", pErr)
+	}
+}
+
 func (p *asyncProducer) returnSuccesses(batch []*ProducerMessage) {
 	for _, msg := range batch {
-		if p.conf.Producer.Return.Successes {
+		p.releaseBuffer(msg)
+		if msg.expectation != nil {
+			msg.clear()
+			msg.expectation <- nil
+		} else if p.conf.Producer.Return.Successes {
 			msg.clear()
 			p.successes <- msg
 		}
@@ -1103,14 +1208,57 @@ func (p *asyncProducer) returnSuccesses(batch []*ProducerMessage) {
 }
 
 func (p *asyncProducer) retryMessage(msg *ProducerMessage, err error) {
-	if msg.retries >= p.conf.Producer.Retry.Max {
+	switch {
+	case p.deliveryExpired(msg):
+		p.returnError(msg, ErrDeliveryTimeout)
+	case msg.retries >= p.conf.Producer.Retry.Max:
 		p.returnError(msg, err)
-	} else {
+	default:
 		msg.retries++
 		p.retries <- msg
 	}
 }
 
+// deliveryExpired returns true if msg was accepted by the producer longer than
+// Producer.DeliveryTimeout ago, in which case it must not be retried anymore.
+func (p *asyncProducer) deliveryExpired(msg *ProducerMessage) bool {
+	return !msg.expiresAt.IsZero() && time.Now().After(msg.expiresAt)
+}
+
+// expireMessages fails the messages of set past their delivery timeout before
+// it is sent. The batches of an idempotent producer cannot be renumbered, so
+// they are failed whole as soon as one of their messages expired.
+func (p *asyncProducer) expireMessages(set *produceSet) {
+	type topicPartition struct {
+		topic     string
+		partition int32
+	}
+	var expired []topicPartition
+	set.eachPartition(func(topic string, partition int32, pSet *partitionSet) {
+		for _, msg := range pSet.msgs {
+			if p.deliveryExpired(msg) {
+				expired = append(expired, topicPartition{topic, partition})
+				return
+			}
+		}
+	})
+
+	for _, tp := range expired {
+		msgs := set.dropPartition(tp.topic, tp.partition)
+		if p.conf.Producer.Idempotent {
+			p.returnErrors(msgs, ErrDeliveryTimeout)
+			continue
+		}
+		for _, msg := range msgs {
+			if p.deliveryExpired(msg) {
+				p.returnError(msg, ErrDeliveryTimeout)
+			} else if err := set.add(msg); err != nil {
+				p.returnError(msg, err)
+			}
+		}
+	}
+}
+
 func (p *asyncProducer) retryMessages(batch []*ProducerMessage, err error) {
 	for _, msg := range batch {
 		p.retryMessage(msg, err)
@@ -1160,3 +1308,61 @@ func (p *asyncProducer) abandonBrokerConnection(broker *Broker) {
 
 	delete(p.brokers, broker)
 }
+
+// producerBuffer is the memory budget shared by the messages in flight when
+// Producer.Buffer.Memory is set.
+type producerBuffer struct {
+	lock      sync.Mutex
+	total     int
+	available int
+	freed     chan none // closed and replaced whenever memory is released
+}
+
+func newProducerBuffer(total int) *producerBuffer {
+	return &producerBuffer{total: total, available: total, freed: make(chan none)}
+}
+
+// acquire reserves size bytes, waiting up to maxBlock for them to be released
+// by other messages, and returns the number of bytes actually reserved. A
+// message bigger than the whole buffer reserves all of it rather than never
+// being admitted.
+func (b *producerBuffer) acquire(size int, maxBlock time.Duration) (int, error) {
+	if size > b.total {
+		size = b.total
+	}
+
+	var timeout <-chan time.Time
+	for {
+		b.lock.Lock()
+		if b.available >= size {
+			b.available -= size
+			b.lock.Unlock()
+			return size, nil
+		}
+		freed := b.freed
+		b.lock.Unlock()
+
+		if timeout == nil {
+			if maxBlock <= 0 {
+				return 0, ErrBufferFull
+			}
+			timer := time.NewTimer(maxBlock)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case <-freed:
+		case <-timeout:
+			return 0, ErrBufferFull
+		}
+	}
+}
+
+func (b *producerBuffer) release(size int) {
+	b.lock.Lock()
+	b.available += size
+	close(b.freed)
+	b.freed = make(chan none)
+	b.lock.Unlock()
+}
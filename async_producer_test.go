@@ -887,6 +887,228 @@ func TestAsyncProducerNoReturns(t *testing.T) {
 	leader.Close()
 }
 
+func TestAsyncProducerBufferFull(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataLeader := new(MetadataResponse)
+	metadataLeader.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataLeader.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataLeader)
+
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewTestConfig()
+	config.Producer.Flush.Frequency = 50 * time.Millisecond
+	config.Producer.Return.Successes = true
+	// room for a single message, which stays buffered until the next flush
+	config.Producer.Buffer.Memory = (&ProducerMessage{Value: StringEncoder(TestMessage)}).byteSize(1)
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Metadata: 1}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Metadata: 2}
+
+	select {
+	case msg := <-producer.Errors():
+		if msg.Err != ErrBufferFull || msg.Msg.Metadata.(int) != 2 {
+			t.Errorf("Expected ErrBufferFull for the second message, got %v for %v", msg.Err, msg.Msg.Metadata)
+		}
+	case <-producer.Successes():
+		t.Error("Expected an error before the first message is flushed")
+	}
+	expectResults(t, producer, 1, 0)
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerBufferBlocks(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataLeader := new(MetadataResponse)
+	metadataLeader.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataLeader.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataLeader)
+
+	prodSuccess := new(ProduceResponse)
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+	leader.Returns(prodSuccess)
+
+	config := NewTestConfig()
+	config.Producer.Flush.Frequency = 50 * time.Millisecond
+	config.Producer.Return.Successes = true
+	config.Producer.Buffer.Memory = (&ProducerMessage{Value: StringEncoder(TestMessage)}).byteSize(1)
+	config.Producer.Buffer.MaxBlock = 5 * time.Second
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+		}
+	}()
+	expectResults(t, producer, 2, 0)
+
+	closeProducer(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerDeliveryTimeout(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t).
+			SetError("my_topic", 0, ErrNotLeaderForPartition),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 1
+	config.Producer.Retry.Max = 100
+	config.Producer.Retry.Backoff = 50 * time.Millisecond
+	config.Producer.DeliveryTimeout = 200 * time.Millisecond
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+
+	select {
+	case msg := <-producer.Errors():
+		if msg.Err != ErrDeliveryTimeout {
+			t.Errorf("Expected ErrDeliveryTimeout, got %v", msg.Err)
+		}
+		if elapsed := time.Since(start); elapsed < config.Producer.DeliveryTimeout {
+			t.Errorf("Message failed after %v, before the delivery timeout", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the message to fail")
+	}
+
+	closeProducer(t, producer)
+	broker.Close()
+}
+
+func TestAsyncProducerDeliveryTimeoutBuffered(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"ProduceRequest": NewMockProduceResponse(t),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 2
+	config.Producer.Flush.Frequency = time.Minute
+	config.Producer.Return.Successes = true
+	config.Producer.DeliveryTimeout = 100 * time.Millisecond
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first message expires while buffered, waiting for the second one
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder("expired"), Metadata: 0}
+	time.Sleep(2 * config.Producer.DeliveryTimeout)
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder("live"), Metadata: 1}
+
+	select {
+	case msg := <-producer.Errors():
+		if msg.Err != ErrDeliveryTimeout || msg.Msg.Metadata.(int) != 0 {
+			t.Errorf("Expected ErrDeliveryTimeout for message 0, got %v for message %v", msg.Err, msg.Msg.Metadata)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the expired message to fail")
+	}
+	select {
+	case msg := <-producer.Successes():
+		if msg.Metadata.(int) != 1 {
+			t.Errorf("Expected message 1 to succeed, got message %v", msg.Metadata)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the live message to succeed")
+	}
+
+	closeProducer(t, producer)
+
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*ProduceRequest); ok {
+			if n := len(req.records["my_topic"][0].MsgSet.Messages); n != 1 {
+				t.Errorf("Expected the expired message not to be sent, got %d messages", n)
+			}
+		}
+	}
+	broker.Close()
+}
+
+func TestAsyncProducerIdempotentDeliveryTimeout(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	broker.SetLatency(10 * time.Millisecond)
+
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()),
+		"InitProducerIDRequest": NewMockWrapper(&InitProducerIDResponse{
+			ProducerID:    1000,
+			ProducerEpoch: 1,
+		}),
+		"ProduceRequest": NewMockProduceResponse(t).
+			SetVersion(3).
+			SetError("my_topic", 0, ErrNotLeaderForPartition),
+	})
+
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 2
+	config.Producer.Flush.Frequency = time.Minute
+	config.Producer.Retry.Max = 1000
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Idempotent = true
+	config.Producer.DeliveryTimeout = 300 * time.Millisecond
+	config.Net.MaxOpenRequests = 1
+	config.Version = V0_11_0_0
+	producer, err := NewAsyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages of the batch expire one after the other while it is retried
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	time.Sleep(config.Producer.DeliveryTimeout / 2)
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-producer.Errors():
+			if msg.Err != ErrDeliveryTimeout {
+				t.Errorf("Expected ErrDeliveryTimeout, got %v", msg.Err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message %d to fail", i)
+		}
+	}
+
+	closeProducer(t, producer)
+	broker.Close()
+}
+
 func TestAsyncProducerIdempotentGoldenPath(t *testing.T) {
 	broker := NewMockBroker(t, 1)
 
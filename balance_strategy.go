@@ -55,25 +55,43 @@ type BalanceStrategy interface {
 	AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error)
 }
 
+// StatefulBalanceStrategy is a BalanceStrategy which takes the previous
+// assignments of the members into account. Members using it report the
+// partitions they were assigned and the generation when joining the group,
+// in ConsumerGroupMemberMetadata version 2, and the leader calls
+// PlanWithPrevious instead of Plan.
+type StatefulBalanceStrategy interface {
+	BalanceStrategy
+
+	// PlanWithPrevious accepts, besides the arguments of Plan, the
+	// assignments of the members in the generation they last took part in,
+	// by member ID, and the ID of the generation being planned. The Topics
+	// of a previous assignment are the partitions reported as owned by the
+	// member, and its UserData the UserData of its metadata, which is the
+	// UserData of its previous assignment unless the member sets its own.
+	// Members which were not assigned partitions before are missing from
+	// previous.
+	PlanWithPrevious(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32, previous map[string]ConsumerGroupMemberAssignment, generationID int32) (BalanceStrategyPlan, error)
+}
+
 // --------------------------------------------------------------------
 
-// BalanceStrategyRange is the default and assigns partitions as ranges to consumer group members.
-// Example with one topic T with six partitions (0..5) and two members (M1, M2):
-//   M1: {T: [0, 1, 2]}
-//   M2: {T: [3, 4, 5]}
-var BalanceStrategyRange = &balanceStrategy{
-	name: RangeBalanceStrategyName,
-	coreFn: func(plan BalanceStrategyPlan, memberIDs []string, topic string, partitions []int32) {
-		step := float64(len(partitions)) / float64(len(memberIDs))
-
-		for i, memberID := range memberIDs {
-			pos := float64(i)
-			min := int(math.Floor(pos*step + 0.5))
-			max := int(math.Floor((pos+1)*step + 0.5))
-			plan.Add(memberID, topic, partitions[min:max]...)
-		}
-	},
-}
+// BalanceStrategyRange is the default and assigns partitions as ranges to
+// consumer group members like the RangeAssignor of the Java client, so groups
+// mixing sarama and Java members get the same assignment whichever member
+// leads the group. For each topic, the members subscribed to it are sorted by
+// member ID and given contiguous ranges of its partitions, the first members
+// getting one more partition when they do not divide evenly. Topics with the
+// same subscribers and number of partitions are thus co-partitioned: each
+// member gets the same partition numbers of all of them, whatever the
+// subscriptions to other topics. Topics subscribed by different sets of
+// members are not, as with the RangeAssignor. The RangeAssignor sorts members
+// with a `group.instance.id` by it instead, which this client does not see, so
+// the assignments only match for groups without static members.
+// Example with topics T1 and T2 with three partitions (0..2) and two members (M1, M2):
+//   M1: {T1: [0, 1], T2: [0, 1]}
+//   M2: {T1: [2], T2: [2]}
+var BalanceStrategyRange = &rangeBalanceStrategy{}
 
 // BalanceStrategySticky assigns partitions to members with an attempt to preserve earlier assignments
 // while maintain a balanced partition distribution.
@@ -90,69 +108,54 @@ var BalanceStrategySticky = &stickyBalanceStrategy{}
 
 // --------------------------------------------------------------------
 
-type balanceStrategy struct {
-	name   string
-	coreFn func(plan BalanceStrategyPlan, memberIDs []string, topic string, partitions []int32)
-}
+type rangeBalanceStrategy struct{}
 
 // Name implements BalanceStrategy.
-func (s *balanceStrategy) Name() string { return s.name }
+func (s *rangeBalanceStrategy) Name() string { return RangeBalanceStrategyName }
 
 // Plan implements BalanceStrategy.
-func (s *balanceStrategy) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
-	// Build members by topic map
+func (s *rangeBalanceStrategy) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	// Build members by topic map, ignoring duplicate subscriptions
 	mbt := make(map[string][]string)
 	for memberID, meta := range members {
+		subscribed := make(map[string]none, len(meta.Topics))
 		for _, topic := range meta.Topics {
-			mbt[topic] = append(mbt[topic], memberID)
+			if _, ok := subscribed[topic]; !ok {
+				subscribed[topic] = none{}
+				mbt[topic] = append(mbt[topic], memberID)
+			}
 		}
 	}
 
-	// Sort members for each topic
-	for topic, memberIDs := range mbt {
-		sort.Sort(&balanceStrategySortable{
-			topic:     topic,
-			memberIDs: memberIDs,
-		})
-	}
-
 	// Assemble plan
 	plan := make(BalanceStrategyPlan, len(members))
 	for topic, memberIDs := range mbt {
-		s.coreFn(plan, memberIDs, topic, topics[topic])
+		sort.Strings(memberIDs)
+
+		partitions := make([]int32, len(topics[topic]))
+		copy(partitions, topics[topic])
+		sort.Sort(int32Slice(partitions))
+
+		perMember, extra := len(partitions)/len(memberIDs), len(partitions)%len(memberIDs)
+		start := 0
+		for i, memberID := range memberIDs {
+			length := perMember
+			if i < extra {
+				length++
+			}
+			plan.Add(memberID, topic, partitions[start:start+length]...)
+			start += length
+		}
 	}
 	return plan, nil
 }
 
-// AssignmentData simple strategies do not require any shared assignment data
-func (s *balanceStrategy) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+// AssignmentData implements BalanceStrategy, the range assignor does not
+// require any shared assignment data.
+func (s *rangeBalanceStrategy) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
 	return nil, nil
 }
 
-type balanceStrategySortable struct {
-	topic     string
-	memberIDs []string
-}
-
-func (p balanceStrategySortable) Len() int { return len(p.memberIDs) }
-func (p balanceStrategySortable) Swap(i, j int) {
-	p.memberIDs[i], p.memberIDs[j] = p.memberIDs[j], p.memberIDs[i]
-}
-func (p balanceStrategySortable) Less(i, j int) bool {
-	return balanceStrategyHashValue(p.topic, p.memberIDs[i]) < balanceStrategyHashValue(p.topic, p.memberIDs[j])
-}
-
-func balanceStrategyHashValue(vv ...string) uint32 {
-	h := uint32(2166136261)
-	for _, s := range vv {
-		for _, c := range s {
-			h ^= uint32(c)
-			h *= 16777619
-		}
-	}
-	return h
-}
-
 type stickyBalanceStrategy struct {
 	movements partitionMovements
 }
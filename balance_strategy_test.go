@@ -13,27 +13,40 @@ import (
 
 func TestBalanceStrategyRange(t *testing.T) {
 	tests := []struct {
+		name     string
 		members  map[string][]string
 		topics   map[string][]int32
 		expected BalanceStrategyPlan
 	}{
 		{
+			name:    "co-partitioned topics",
 			members: map[string][]string{"M1": {"T1", "T2"}, "M2": {"T1", "T2"}},
 			topics:  map[string][]int32{"T1": {0, 1, 2, 3}, "T2": {0, 1, 2, 3}},
 			expected: BalanceStrategyPlan{
-				"M1": map[string][]int32{"T1": {0, 1}, "T2": {2, 3}},
-				"M2": map[string][]int32{"T1": {2, 3}, "T2": {0, 1}},
+				"M1": map[string][]int32{"T1": {0, 1}, "T2": {0, 1}},
+				"M2": map[string][]int32{"T1": {2, 3}, "T2": {2, 3}},
 			},
 		},
 		{
+			name:    "co-partitioned topics with extra partitions",
 			members: map[string][]string{"M1": {"T1", "T2"}, "M2": {"T1", "T2"}},
 			topics:  map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1, 2}},
 			expected: BalanceStrategyPlan{
-				"M1": map[string][]int32{"T1": {0, 1}, "T2": {2}},
-				"M2": map[string][]int32{"T1": {2}, "T2": {0, 1}},
+				"M1": map[string][]int32{"T1": {0, 1}, "T2": {0, 1}},
+				"M2": map[string][]int32{"T1": {2}, "T2": {2}},
+			},
+		},
+		{
+			name:    "co-partitioned topics with uneven subscriptions",
+			members: map[string][]string{"M1": {"T1", "T2", "T3"}, "M2": {"T1", "T2"}},
+			topics:  map[string][]int32{"T1": {0, 1}, "T2": {0, 1}, "T3": {0, 1}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0}, "T2": {0}, "T3": {0, 1}},
+				"M2": map[string][]int32{"T1": {1}, "T2": {1}},
 			},
 		},
 		{
+			name:    "members subscribed to distinct topics",
 			members: map[string][]string{"M1": {"T1"}, "M2": {"T1", "T2"}},
 			topics:  map[string][]int32{"T1": {0, 1}, "T2": {0, 1}},
 			expected: BalanceStrategyPlan{
@@ -41,6 +54,34 @@ func TestBalanceStrategyRange(t *testing.T) {
 				"M2": map[string][]int32{"T1": {1}, "T2": {0, 1}},
 			},
 		},
+		{
+			name:    "distinct subscribers",
+			members: map[string][]string{"M1": {"T1"}, "M2": {"T1", "T2"}, "M3": {"T2"}},
+			topics:  map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1, 2}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0, 1}},
+				"M2": map[string][]int32{"T1": {2}, "T2": {0, 1}},
+				"M3": map[string][]int32{"T2": {2}},
+			},
+		},
+		{
+			name:    "more members than partitions",
+			members: map[string][]string{"M1": {"T1"}, "M2": {"T1"}, "M3": {"T1"}},
+			topics:  map[string][]int32{"T1": {0, 1}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0}},
+				"M2": map[string][]int32{"T1": {1}},
+			},
+		},
+		{
+			name:    "members sorted lexicographically and partitions sorted",
+			members: map[string][]string{"consumer-2": {"T1"}, "consumer-10": {"T1", "T1"}},
+			topics:  map[string][]int32{"T1": {4, 2, 0, 3, 1}},
+			expected: BalanceStrategyPlan{
+				"consumer-10": map[string][]int32{"T1": {0, 1, 2}},
+				"consumer-2":  map[string][]int32{"T1": {3, 4}},
+			},
+		},
 	}
 
 	strategy := BalanceStrategyRange
@@ -49,17 +90,19 @@ func TestBalanceStrategyRange(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		members := make(map[string]ConsumerGroupMemberMetadata)
-		for memberID, topics := range test.members {
-			members[memberID] = ConsumerGroupMemberMetadata{Topics: topics}
-		}
+		t.Run(test.name, func(t *testing.T) {
+			members := make(map[string]ConsumerGroupMemberMetadata)
+			for memberID, topics := range test.members {
+				members[memberID] = ConsumerGroupMemberMetadata{Topics: topics}
+			}
 
-		actual, err := strategy.Plan(members, test.topics)
-		if err != nil {
-			t.Errorf("Unexpected error %v", err)
-		} else if !reflect.DeepEqual(actual, test.expected) {
-			t.Errorf("Plan does not match expectation\nexpected: %#v\nactual: %#v", test.expected, actual)
-		}
+			actual, err := strategy.Plan(members, test.topics)
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			} else if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("Plan does not match expectation\nexpected: %#v\nactual: %#v", test.expected, actual)
+			}
+		})
 	}
 }
 
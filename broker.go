@@ -3,8 +3,10 @@ package sarama
 import (
 	"crypto/tls"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"sort"
 	"strconv"
@@ -26,10 +28,18 @@ type Broker struct {
 	correlationID int32
 	conn          net.Conn
 	connErr       error
+	logger        fieldLogger
 	lock          sync.Mutex
 	opened        int32
 	responses     chan responsePromise
 	done          chan bool
+	pending       sync.WaitGroup
+
+	// sessionLifetimeMs is the SASL session lifetime reported by the broker
+	// during the last authentication, and sessionReauthenticationTime the
+	// time after which the session is re-authenticated (KIP-368).
+	sessionLifetimeMs           int64
+	sessionReauthenticationTime time.Time
 
 	registeredMetrics []string
 
@@ -51,6 +61,37 @@ type Broker struct {
 	brokerRequestsInFlight metrics.Counter
 
 	kerberosAuthenticator GSSAPIKerberosAuth
+
+	// dedicated are the connections opened for some kinds of requests with
+	// Net.DedicatedConnections, and primary the Broker owning a dedicated
+	// connection, whose metrics it shares.
+	dedicated [numConnectionClasses]*Broker
+	primary   *Broker
+}
+
+// connectionClass is the kind of connection a request is sent on with
+// Net.DedicatedConnections.
+type connectionClass int8
+
+const (
+	defaultConnection connectionClass = iota
+	groupConnection
+	fetchConnection
+	produceConnection
+	numConnectionClasses
+)
+
+func connectionClassOf(req protocolBody) connectionClass {
+	switch req.(type) {
+	case *JoinGroupRequest, *SyncGroupRequest, *HeartbeatRequest, *LeaveGroupRequest, *OffsetCommitRequest, *OffsetFetchRequest:
+		return groupConnection
+	case *FetchRequest:
+		return fetchConnection
+	case *ProduceRequest:
+		return produceConnection
+	default:
+		return defaultConnection
+	}
 }
 
 // SASLMechanism specifies the SASL mechanism the client uses to authenticate with the broker
@@ -154,10 +195,15 @@ func (b *Broker) Open(conf *Config) error {
 	go withRecover(func() {
 		defer b.lock.Unlock()
 
-		dialer := conf.getDialer()
-		b.conn, b.connErr = dialer.Dial("tcp", b.addr)
+		if b.id >= 0 {
+			b.logger = conf.logger(LogField{LogFieldBroker, b.id})
+		} else {
+			b.logger = conf.logger()
+		}
+
+		b.conn, b.connErr = conf.dial(b.addr)
 		if b.connErr != nil {
-			Logger.Printf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
+			b.logger.Errorf("Failed to connect to broker %s: %s\n", b.addr, b.connErr)
 			b.conn = nil
 			atomic.StoreInt32(&b.opened, 0)
 			return
@@ -181,7 +227,11 @@ func (b *Broker) Open(conf *Config) error {
 		// Do not gather metrics for seeded broker (only used during bootstrap) because they share
 		// the same id (-1) and are already exposed through the global metrics above
 		if b.id >= 0 {
-			b.registerMetrics()
+			if b.primary != nil {
+				b.shareMetrics(b.primary)
+			} else {
+				b.registerMetrics()
+			}
 		}
 
 		if conf.Net.SASL.Enable {
@@ -190,9 +240,9 @@ func (b *Broker) Open(conf *Config) error {
 			if b.connErr != nil {
 				err = b.conn.Close()
 				if err == nil {
-					Logger.Printf("Closed connection to broker %s\n", b.addr)
+					b.logger.Infof("Closed connection to broker %s\n", b.addr)
 				} else {
-					Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+					b.logger.Warnf("Error while closing connection to broker %s: %s\n", b.addr, err)
 				}
 				b.conn = nil
 				atomic.StoreInt32(&b.opened, 0)
@@ -204,9 +254,9 @@ func (b *Broker) Open(conf *Config) error {
 		b.responses = make(chan responsePromise, b.conf.Net.MaxOpenRequests-1)
 
 		if b.id >= 0 {
-			Logger.Printf("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
+			b.logger.Infof("Connected to broker at %s (registered as #%d)\n", b.addr, b.id)
 		} else {
-			Logger.Printf("Connected to broker at %s (unregistered)\n", b.addr)
+			b.logger.Infof("Connected to broker at %s (unregistered)\n", b.addr)
 		}
 		go withRecover(b.responseReceiver)
 	})
@@ -228,6 +278,8 @@ func (b *Broker) Close() error {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	b.closeDedicatedConnections()
+
 	if b.conn == nil {
 		return ErrNotConnected
 	}
@@ -241,13 +293,14 @@ func (b *Broker) Close() error {
 	b.connErr = nil
 	b.done = nil
 	b.responses = nil
+	b.sessionReauthenticationTime = time.Time{}
 
 	b.unregisterMetrics()
 
 	if err == nil {
-		Logger.Printf("Closed connection to broker %s\n", b.addr)
+		b.logger.Infof("Closed connection to broker %s\n", b.addr)
 	} else {
-		Logger.Printf("Error while closing connection to broker %s: %s\n", b.addr, err)
+		b.logger.Warnf("Error while closing connection to broker %s: %s\n", b.addr, err)
 	}
 
 	atomic.StoreInt32(&b.opened, 0)
@@ -255,6 +308,44 @@ func (b *Broker) Close() error {
 	return err
 }
 
+// dedicatedConnection returns the Broker to send req on with
+// Net.DedicatedConnections, opening its connection if needed, or nil if req
+// is to be sent on the connection of b.
+func (b *Broker) dedicatedConnection(req protocolBody) *Broker {
+	class := connectionClassOf(req)
+	if class == defaultConnection || b.primary != nil {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.conn == nil || !b.conf.Net.DedicatedConnections {
+		// let send report the connection error
+		return nil
+	}
+
+	conn := b.dedicated[class]
+	if conn == nil {
+		conn = &Broker{id: b.id, addr: b.addr, rack: b.rack, primary: b}
+		b.dedicated[class] = conn
+	}
+	// a no-op if it is already connected or connecting
+	_ = conn.Open(b.conf)
+	return conn
+}
+
+// closeDedicatedConnections closes the connections opened by
+// dedicatedConnection. You must hold the lock before calling this function.
+func (b *Broker) closeDedicatedConnections() {
+	for class, conn := range b.dedicated {
+		if conn != nil {
+			_ = conn.Close()
+			b.dedicated[class] = nil
+		}
+	}
+}
+
 // ID returns the broker ID retrieved from Kafka's metadata, or -1 if that is not known.
 func (b *Broker) ID() int32 {
 	return b.id
@@ -276,6 +367,16 @@ func (b *Broker) Rack() string {
 	return *b.rack
 }
 
+// MarshalJSON encodes the broker's ID, address and rack, so that the decoded
+// requests and responses referring to brokers can be printed as JSON.
+func (b *Broker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID   int32   `json:"id"`
+		Addr string  `json:"addr"`
+		Rack *string `json:"rack,omitempty"`
+	}{b.id, b.addr, b.rack})
+}
+
 //GetMetadata send a metadata request and returns a metadata response or error
 func (b *Broker) GetMetadata(request *MetadataRequest) (*MetadataResponse, error) {
 	response := new(MetadataResponse)
@@ -751,6 +852,12 @@ func (b *Broker) send(rb protocolBody, promiseResponse bool, responseHeaderVersi
 		return nil, ErrUnsupportedVersion
 	}
 
+	if !b.sessionReauthenticationTime.IsZero() && time.Now().After(b.sessionReauthenticationTime) {
+		if err := b.reauthenticate(); err != nil {
+			return nil, err
+		}
+	}
+
 	req := &request{correlationID: b.correlationID, clientID: b.conf.ClientID, body: rb}
 	buf, err := encode(req, b.conf.MetricRegistry)
 	if err != nil {
@@ -775,6 +882,7 @@ func (b *Broker) send(rb protocolBody, promiseResponse bool, responseHeaderVersi
 	}
 
 	promise := responsePromise{requestTime, req.correlationID, responseHeaderVersion, make(chan []byte), make(chan error)}
+	b.pending.Add(1)
 	b.responses <- promise
 
 	return &promise, nil
@@ -786,6 +894,10 @@ func (b *Broker) sendAndReceive(req protocolBody, res protocolBody) error {
 		responseHeaderVersion = res.headerVersion()
 	}
 
+	if conn := b.dedicatedConnection(req); conn != nil {
+		return conn.sendAndReceive(req, res)
+	}
+
 	promise, err := b.send(req, res != nil, responseHeaderVersion)
 	if err != nil {
 		return err
@@ -872,6 +984,7 @@ func (b *Broker) responseReceiver() {
 			// This was previously incremented in send() and
 			// we are not calling updateIncomingCommunicationMetrics()
 			b.addRequestInFlightMetrics(-1)
+			b.pending.Done()
 			response.errors <- dead
 			continue
 		}
@@ -884,6 +997,7 @@ func (b *Broker) responseReceiver() {
 		if err != nil {
 			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
 			dead = err
+			b.pending.Done()
 			response.errors <- err
 			continue
 		}
@@ -893,6 +1007,7 @@ func (b *Broker) responseReceiver() {
 		if err != nil {
 			b.updateIncomingCommunicationMetrics(bytesReadHeader, requestLatency)
 			dead = err
+			b.pending.Done()
 			response.errors <- err
 			continue
 		}
@@ -901,6 +1016,7 @@ func (b *Broker) responseReceiver() {
 			// TODO if decoded ID < cur ID, discard until we catch up
 			// TODO if decoded ID > cur ID, save it so when cur ID catches up we have a response
 			dead = PacketDecodingError{fmt.Sprintf("correlation ID didn't match, wanted %d, got %d", response.correlationID, decodedHeader.correlationID)}
+			b.pending.Done()
 			response.errors <- dead
 			continue
 		}
@@ -908,6 +1024,9 @@ func (b *Broker) responseReceiver() {
 		buf := make([]byte, decodedHeader.length-int32(headerLength)+4)
 		bytesReadBody, err := b.readFull(buf)
 		b.updateIncomingCommunicationMetrics(bytesReadHeader+bytesReadBody, requestLatency)
+		// the connection is not read from anymore for this response, let a
+		// pending re-authentication proceed
+		b.pending.Done()
 		if err != nil {
 			dead = err
 			response.errors <- err
@@ -929,6 +1048,50 @@ func getHeaderLength(headerVersion int16) int8 {
 }
 
 func (b *Broker) authenticateViaSASL() error {
+	authStart := time.Now()
+	b.sessionLifetimeMs = 0
+	b.sessionReauthenticationTime = time.Time{}
+
+	if err := b.runSASLMechanism(); err != nil {
+		return err
+	}
+
+	// Like the Java client, re-authenticate at a random point between 85% and
+	// 95% of the session lifetime so that the new session is established
+	// before the broker starts rejecting requests on the connection.
+	if b.sessionLifetimeMs > 0 {
+		lifetime := time.Duration(b.sessionLifetimeMs) * time.Millisecond
+		b.sessionReauthenticationTime = authStart.Add(time.Duration(float64(lifetime) * (0.85 + 0.1*rand.Float64())))
+	}
+	return nil
+}
+
+// reauthenticate runs the SASL authentication again over the current
+// connection as described by KIP-368. It is called from send with b.lock held,
+// so no new request can be written meanwhile, and waits for the responses to
+// all the requests already in flight to be read by the responseReceiver so
+// that the SASL exchange has exclusive use of the connection.
+func (b *Broker) reauthenticate() error {
+	b.pending.Wait()
+
+	b.logger.Debugf("Re-authenticating with broker %s\n", b.addr)
+	if err := b.authenticateViaSASL(); err != nil {
+		b.logger.Errorf("Error while re-authenticating with broker %s: %s\n", b.addr, err)
+		return err
+	}
+	return nil
+}
+
+// saslAuthenticateVersion returns the SaslAuthenticate version to use. v1 is
+// needed for the broker to report the session lifetime.
+func (b *Broker) saslAuthenticateVersion() int16 {
+	if b.conf.Version.IsAtLeast(V2_2_0_0) {
+		return 1
+	}
+	return 0
+}
+
+func (b *Broker) runSASLMechanism() error {
 	switch b.conf.Net.SASL.Mechanism {
 	case SASLTypeOAuth:
 		return b.sendAndReceiveSASLOAuth(b.conf.Net.SASL.TokenProvider)
@@ -965,7 +1128,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	b.updateOutgoingCommunicationMetrics(bytes)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to send SASL handshake %s: %s\n", b.addr, err.Error())
+		b.logger.Errorf("Failed to send SASL handshake %s: %s\n", b.addr, err.Error())
 		return err
 	}
 	b.correlationID++
@@ -974,7 +1137,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	_, err = b.readFull(header)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to read SASL handshake header : %s\n", err.Error())
+		b.logger.Errorf("Failed to read SASL handshake header : %s\n", err.Error())
 		return err
 	}
 
@@ -983,7 +1146,7 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 	n, err := b.readFull(payload)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to read SASL handshake payload : %s\n", err.Error())
+		b.logger.Errorf("Failed to read SASL handshake payload : %s\n", err.Error())
 		return err
 	}
 
@@ -992,16 +1155,16 @@ func (b *Broker) sendAndReceiveSASLHandshake(saslType SASLMechanism, version int
 
 	err = versionedDecode(payload, res, 0)
 	if err != nil {
-		Logger.Printf("Failed to parse SASL handshake : %s\n", err.Error())
+		b.logger.Errorf("Failed to parse SASL handshake : %s\n", err.Error())
 		return err
 	}
 
 	if res.Err != ErrNoError {
-		Logger.Printf("Invalid SASL Mechanism : %s\n", res.Err.Error())
+		b.logger.Errorf("Invalid SASL Mechanism : %s\n", res.Err.Error())
 		return res.Err
 	}
 
-	Logger.Print("Successful SASL handshake. Available mechanisms: ", res.EnabledMechanisms)
+	b.logger.Debugf("Successful SASL handshake. Available mechanisms: %v", res.EnabledMechanisms)
 	return nil
 }
 
@@ -1035,7 +1198,7 @@ func (b *Broker) sendAndReceiveSASLPlainAuth() error {
 	if b.conf.Net.SASL.Handshake {
 		handshakeErr := b.sendAndReceiveSASLHandshake(SASLTypePlaintext, b.conf.Net.SASL.Version)
 		if handshakeErr != nil {
-			Logger.Printf("Error while performing SASL handshake %s\n", b.addr)
+			b.logger.Errorf("Error while performing SASL handshake %s\n", b.addr)
 			return handshakeErr
 		}
 	}
@@ -1060,7 +1223,7 @@ func (b *Broker) sendAndReceiveV0SASLPlainAuth() error {
 	b.updateOutgoingCommunicationMetrics(bytesWritten)
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+		b.logger.Errorf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
 		return err
 	}
 
@@ -1070,11 +1233,11 @@ func (b *Broker) sendAndReceiveV0SASLPlainAuth() error {
 	// If the credentials are valid, we would get a 4 byte response filled with null characters.
 	// Otherwise, the broker closes the connection and we get an EOF
 	if err != nil {
-		Logger.Printf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+		b.logger.Errorf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
 		return err
 	}
 
-	Logger.Printf("SASL authentication successful with broker %s:%v - %v\n", b.addr, n, header)
+	b.logger.Debugf("SASL authentication successful with broker %s:%v - %v\n", b.addr, n, header)
 	return nil
 }
 
@@ -1091,7 +1254,7 @@ func (b *Broker) sendAndReceiveV1SASLPlainAuth() error {
 
 	if err != nil {
 		b.addRequestInFlightMetrics(-1)
-		Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+		b.logger.Errorf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
 		return err
 	}
 
@@ -1102,7 +1265,7 @@ func (b *Broker) sendAndReceiveV1SASLPlainAuth() error {
 
 	// With v1 sasl we get an error message set in the response we can return
 	if err != nil {
-		Logger.Printf("Error returned from broker during SASL flow %s: %s\n", b.addr, err.Error())
+		b.logger.Errorf("Error returned from broker during SASL flow %s: %s\n", b.addr, err.Error())
 		return err
 	}
 
@@ -1166,7 +1329,7 @@ func (b *Broker) sendClientMessage(message []byte) (bool, error) {
 	isChallenge := len(res.SaslAuthBytes) > 0
 
 	if isChallenge && err != nil {
-		Logger.Printf("Broker rejected authentication token: %s", res.SaslAuthBytes)
+		b.logger.Errorf("Broker rejected authentication token: %s", res.SaslAuthBytes)
 	}
 
 	return isChallenge, err
@@ -1196,7 +1359,7 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 		b.updateOutgoingCommunicationMetrics(bytesWritten)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
+			b.logger.Errorf("Failed to write SASL auth header to broker %s: %s\n", b.addr, err.Error())
 			return err
 		}
 
@@ -1204,24 +1367,24 @@ func (b *Broker) sendAndReceiveSASLSCRAMv1() error {
 		challenge, err := b.receiveSaslAuthenticateResponse(correlationID)
 		if err != nil {
 			b.addRequestInFlightMetrics(-1)
-			Logger.Printf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
+			b.logger.Errorf("Failed to read response while authenticating with SASL to broker %s: %s\n", b.addr, err.Error())
 			return err
 		}
 
 		b.updateIncomingCommunicationMetrics(len(challenge), time.Since(requestTime))
 		msg, err = scramClient.Step(string(challenge))
 		if err != nil {
-			Logger.Println("SASL authentication failed", err)
+			b.logger.Errorf("SASL authentication failed %s", err)
 			return err
 		}
 	}
 
-	Logger.Println("SASL authentication succeeded")
+	b.logger.Debugf("SASL authentication succeeded")
 	return nil
 }
 
 func (b *Broker) sendSaslAuthenticateRequest(correlationID int32, msg []byte) (int, error) {
-	rb := &SaslAuthenticateRequest{msg}
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: msg}
 	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
 	buf, err := encode(req, b.conf.MetricRegistry)
 	if err != nil {
@@ -1255,12 +1418,13 @@ func (b *Broker) receiveSaslAuthenticateResponse(correlationID int32) ([]byte, e
 	}
 
 	res := &SaslAuthenticateResponse{}
-	if err := versionedDecode(buf, res, 0); err != nil {
+	if err := versionedDecode(buf, res, b.saslAuthenticateVersion()); err != nil {
 		return nil, err
 	}
 	if res.Err != ErrNoError {
 		return nil, res.Err
 	}
+	b.sessionLifetimeMs = res.SessionLifetimeMs
 	return res.SaslAuthBytes, nil
 }
 
@@ -1297,7 +1461,7 @@ func mapToString(extensions map[string]string, keyValSep string, elemSep string)
 
 func (b *Broker) sendSASLPlainAuthClientResponse(correlationID int32) (int, error) {
 	authBytes := []byte(b.conf.Net.SASL.AuthIdentity + "\x00" + b.conf.Net.SASL.User + "\x00" + b.conf.Net.SASL.Password)
-	rb := &SaslAuthenticateRequest{authBytes}
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: authBytes}
 	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
 	buf, err := encode(req, b.conf.MetricRegistry)
 	if err != nil {
@@ -1308,7 +1472,7 @@ func (b *Broker) sendSASLPlainAuthClientResponse(correlationID int32) (int, erro
 }
 
 func (b *Broker) sendSASLOAuthBearerClientMessage(initialResp []byte, correlationID int32) (int, error) {
-	rb := &SaslAuthenticateRequest{initialResp}
+	rb := &SaslAuthenticateRequest{Version: b.saslAuthenticateVersion(), SaslAuthBytes: initialResp}
 
 	req := &request{correlationID: correlationID, clientID: b.conf.ClientID, body: rb}
 
@@ -1344,7 +1508,7 @@ func (b *Broker) receiveSASLServerResponse(res *SaslAuthenticateResponse, correl
 		return bytesRead, err
 	}
 
-	if err := versionedDecode(buf, res, 0); err != nil {
+	if err := versionedDecode(buf, res, b.saslAuthenticateVersion()); err != nil {
 		return bytesRead, err
 	}
 
@@ -1352,6 +1516,7 @@ func (b *Broker) receiveSASLServerResponse(res *SaslAuthenticateResponse, correl
 		return bytesRead, res.Err
 	}
 
+	b.sessionLifetimeMs = res.SessionLifetimeMs
 	return bytesRead, nil
 }
 
@@ -1422,6 +1587,19 @@ func (b *Broker) registerMetrics() {
 	b.brokerRequestsInFlight = b.registerCounter("requests-in-flight")
 }
 
+// shareMetrics makes b update the metrics of the broker registered by primary,
+// which b must not unregister.
+func (b *Broker) shareMetrics(primary *Broker) {
+	b.brokerIncomingByteRate = primary.brokerIncomingByteRate
+	b.brokerRequestRate = primary.brokerRequestRate
+	b.brokerRequestSize = primary.brokerRequestSize
+	b.brokerRequestLatency = primary.brokerRequestLatency
+	b.brokerOutgoingByteRate = primary.brokerOutgoingByteRate
+	b.brokerResponseRate = primary.brokerResponseRate
+	b.brokerResponseSize = primary.brokerResponseSize
+	b.brokerRequestsInFlight = primary.brokerRequestsInFlight
+}
+
 func (b *Broker) unregisterMetrics() {
 	for _, name := range b.registeredMetrics {
 		b.conf.MetricRegistry.Unregister(name)
@@ -2,10 +2,12 @@ package sarama
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -76,6 +78,10 @@ func TestBrokerAccessors(t *testing.T) {
 	if broker.Rack() != rack {
 		t.Error("Manually setting broker rack did not take effect.")
 	}
+
+	if b, err := json.Marshal(broker); err != nil || string(b) != `{"id":34,"addr":"abc:123","rack":"dc1"}` {
+		t.Errorf("Unexpected JSON encoding %s, err=%v", b, err)
+	}
 }
 
 func TestSimpleBrokerCommunication(t *testing.T) {
@@ -135,6 +141,74 @@ func newTokenProvider(token *AccessToken, err error) *TokenProvider {
 	}
 }
 
+func TestBrokerDedicatedConnections(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	remotes := make(map[int16]string)
+	server := NewServer(ServerHandlerFunc(func(req *Request) (ProtocolBody, error) {
+		lock.Lock()
+		remotes[req.APIKey] = req.RemoteAddr.String()
+		lock.Unlock()
+
+		switch body := req.Body.(type) {
+		case *MetadataRequest:
+			return &MetadataResponse{Version: body.Version}, nil
+		case *FetchRequest:
+			return &FetchResponse{Version: body.Version}, nil
+		case *HeartbeatRequest:
+			return &HeartbeatResponse{}, nil
+		default:
+			t.Errorf("Unexpected request %T", body)
+			return nil, nil
+		}
+	}))
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Close()
+
+	for _, dedicated := range []bool{false, true} {
+		conf := NewTestConfig()
+		conf.Version = V1_0_0_0
+		conf.Net.DedicatedConnections = dedicated
+		broker := NewBroker(l.Addr().String())
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := broker.Fetch(&FetchRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := broker.Heartbeat(&HeartbeatRequest{}); err != nil {
+			t.Fatal(err)
+		}
+
+		lock.Lock()
+		metadata, fetch, heartbeat := remotes[3], remotes[1], remotes[12]
+		lock.Unlock()
+		if dedicated && (metadata == fetch || metadata == heartbeat || fetch == heartbeat) {
+			t.Errorf("Expected separate connections, got metadata on %s, fetch on %s and heartbeat on %s", metadata, fetch, heartbeat)
+		}
+		if !dedicated && (metadata != fetch || metadata != heartbeat) {
+			t.Errorf("Expected a single connection, got metadata on %s, fetch on %s and heartbeat on %s", metadata, fetch, heartbeat)
+		}
+
+		safeClose(t, broker)
+		for class, conn := range broker.dedicated {
+			if conn != nil {
+				t.Errorf("Expected dedicated connection %d to be closed", class)
+			}
+		}
+	}
+}
+
 func TestSASLOAuthBearer(t *testing.T) {
 	testTable := []struct {
 		name                      string
@@ -525,6 +599,60 @@ func TestSASLPlainAuth(t *testing.T) {
 	}
 }
 
+// TestSASLReauthentication ensures that the broker re-authenticates on the
+// same connection once the session lifetime returned by the broker expires
+func TestSASLReauthentication(t *testing.T) {
+	mockBroker := NewMockBroker(t, 0)
+	defer mockBroker.Close()
+
+	mockBroker.SetHandlerByMap(map[string]MockResponse{
+		"SaslAuthenticateRequest": NewMockSaslAuthenticateResponse(t).SetSessionLifetimeMs(100),
+		"SaslHandshakeRequest":    NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{SASLTypePlaintext}),
+		"MetadataRequest":         NewMockMetadataResponse(t),
+	})
+
+	conf := NewTestConfig()
+	conf.Version = V2_2_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypePlaintext
+	conf.Net.SASL.User = "token"
+	conf.Net.SASL.Password = "password"
+	conf.Net.SASL.Version = SASLHandshakeV1
+
+	broker := NewBroker(mockBroker.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, broker)
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []string
+	for _, rr := range mockBroker.History() {
+		switch r := rr.Request.(type) {
+		case *SaslHandshakeRequest:
+			requests = append(requests, "handshake")
+		case *SaslAuthenticateRequest:
+			if r.Version != 1 {
+				t.Errorf("Expected SaslAuthenticate v1, got v%d", r.Version)
+			}
+			requests = append(requests, "authenticate")
+		case *MetadataRequest:
+			requests = append(requests, "metadata")
+		}
+	}
+	expected := []string{"handshake", "authenticate", "metadata", "handshake", "authenticate", "metadata"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+}
+
 // TestSASLReadTimeout ensures that the broker connection won't block forever
 // if the remote end never responds after the handshake
 func TestSASLReadTimeout(t *testing.T) {
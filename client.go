@@ -66,7 +66,10 @@ type Client interface {
 
 	// RefreshMetadata takes a list of topics and queries the cluster to refresh the
 	// available metadata for those topics. If no topics are provided, it will refresh
-	// metadata for all topics.
+	// metadata for all topics. Refreshes of a topic are spaced by
+	// Metadata.MinRefreshInterval, and calls for the same topics made while a
+	// refresh waits share its request. A call only fails on the errors of its
+	// own topics.
 	RefreshMetadata(topics ...string) error
 
 	// GetOffset queries the cluster to get the most recent available offset at the
@@ -75,6 +78,15 @@ type Client interface {
 	// OffsetNewest for the offset of the message that will be produced next, or a time.
 	GetOffset(topic string, partitionID int32, time int64) (int64, error)
 
+	// GetOffsets queries the cluster to get the offsets at the given times (in
+	// milliseconds, or OffsetOldest/OffsetNewest) for many topic/partitions at
+	// once. Partitions are grouped by leader and a single OffsetRequest is sent
+	// to each broker concurrently. Partitions failing because of a leadership
+	// change are retried after a metadata refresh, up to Metadata.Retry.Max
+	// times. The result contains an entry for every requested topic/partition,
+	// with its own error if the offset could not be retrieved.
+	GetOffsets(times map[string]map[int32]int64) (map[string]map[int32]*OffsetResult, error)
+
 	// Coordinator returns the coordinating broker for a consumer group. It will
 	// return a locally cached value if it's available. You can call
 	// RefreshCoordinator to update the cached value. This function only works on
@@ -88,6 +100,13 @@ type Client interface {
 	// InitProducerID retrieves information required for Idempotent Producer
 	InitProducerID() (*InitProducerIDResponse, error)
 
+	// SubscribeMetadataChanges returns a channel receiving the changes found
+	// between successive metadata responses, and a function to call to stop
+	// the subscription. Changes are dropped rather than delaying metadata
+	// updates if the channel, buffered with Config.ChannelBufferSize, is full.
+	// The channel is closed when unsubscribing or closing the client.
+	SubscribeMetadataChanges() (<-chan MetadataChange, func())
+
 	// Close shuts down all broker connections managed by this client. It is required
 	// to call this function before a client object passes out of scope, as it will
 	// otherwise leak memory. You must close any Producers or Consumers using a client
@@ -111,6 +130,19 @@ const (
 	OffsetOldest int64 = -2
 )
 
+// OffsetResult is the outcome of looking up the offset of a single
+// topic/partition with Client.GetOffsets.
+type OffsetResult struct {
+	// Offset is the offset found for the requested time, or -1 if there is
+	// no such offset.
+	Offset int64
+	// Timestamp is the timestamp associated with Offset (in milliseconds).
+	// Only set if kafka is version 0.10.1+ and a time was requested.
+	Timestamp int64
+	// Err is the error that prevented the offset from being retrieved, if any.
+	Err error
+}
+
 type client struct {
 	conf           *Config
 	closer, closed chan none // for shutting down background metadata updater
@@ -126,6 +158,12 @@ type client struct {
 	metadata       map[string]map[int32]*PartitionMetadata // maps topics to partition ids to metadata
 	metadataTopics map[string]none                         // topics that need to collect metadata
 	coordinators   map[string]int32                        // Maps consumer group names to coordinating broker IDs
+	subscribers    map[chan MetadataChange]none            // receive the changes made by metadata updates
+
+	refreshLock     sync.Mutex           // protects the fields coalescing metadata refreshes
+	refreshes       []*metadataRefresh   // metadata requests in flight
+	refreshedAt     map[string]time.Time // when the metadata of each topic was last refreshed
+	fullRefreshedAt time.Time            // when the metadata of all topics was last refreshed
 
 	// If the number of partitions is large, we can get some churn calling cachedPartitions,
 	// so the result is cached.  It is important to update this value whenever metadata is changed
@@ -138,7 +176,7 @@ type client struct {
 // and uses that broker to automatically fetch metadata on the rest of the kafka cluster. If metadata cannot
 // be retrieved from any of the given broker addresses, the client is not created.
 func NewClient(addrs []string, conf *Config) (Client, error) {
-	Logger.Println("Initializing new client")
+	conf.logger().Infof("Initializing new client")
 
 	if conf == nil {
 		conf = NewConfig()
@@ -161,6 +199,8 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 		metadataTopics:          make(map[string]none),
 		cachedPartitionsResults: make(map[string][maxPartitionIndex][]int32),
 		coordinators:            make(map[string]int32),
+		subscribers:             make(map[chan MetadataChange]none),
+		refreshedAt:             make(map[string]time.Time),
 	}
 
 	client.randomizeSeedBrokers(addrs)
@@ -173,7 +213,7 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 			break
 		case ErrLeaderNotAvailable, ErrReplicaNotAvailable, ErrTopicAuthorizationFailed, ErrClusterAuthorizationFailed:
 			// indicates that maybe part of the cluster is down, but is not fatal to creating the client
-			Logger.Println(err)
+			client.conf.logger().Warnf("%s", err)
 		default:
 			close(client.closed) // we haven't started the background updater yet, so we have to do this manually
 			_ = client.Close()
@@ -182,7 +222,7 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 	}
 	go withRecover(client.backgroundMetadataUpdater)
 
-	Logger.Println("Successfully initialized new client")
+	client.conf.logger().Infof("Successfully initialized new client")
 
 	return client, nil
 }
@@ -223,7 +263,7 @@ func (client *client) InitProducerID() (*InitProducerIDResponse, error) {
 			return response, nil
 		default:
 			// some error, remove that broker and try again
-			Logger.Printf("Client got error from broker %d when issuing InitProducerID : %v\n", broker.ID(), err)
+			client.conf.logger().Warnf("Client got error from broker %d when issuing InitProducerID : %v\n", broker.ID(), err)
 			_ = broker.Close()
 			client.deregisterBroker(broker)
 		}
@@ -235,7 +275,7 @@ func (client *client) Close() error {
 	if client.Closed() {
 		// Chances are this is being called from a defer() and the error will go unobserved
 		// so we go ahead and log the event in this case.
-		Logger.Printf("Close() called on already closed client")
+		client.conf.logger().Warnf("Close() called on already closed client")
 		return ErrClosedClient
 	}
 
@@ -245,7 +285,7 @@ func (client *client) Close() error {
 
 	client.lock.Lock()
 	defer client.lock.Unlock()
-	Logger.Println("Closing Client")
+	client.conf.logger().Infof("Closing Client")
 
 	for _, broker := range client.brokers {
 		safeAsyncClose(broker)
@@ -255,13 +295,41 @@ func (client *client) Close() error {
 		safeAsyncClose(broker)
 	}
 
+	for ch := range client.subscribers {
+		close(ch)
+	}
+
 	client.brokers = nil
 	client.metadata = nil
 	client.metadataTopics = nil
+	client.subscribers = nil
 
 	return nil
 }
 
+func (client *client) SubscribeMetadataChanges() (<-chan MetadataChange, func()) {
+	ch := make(chan MetadataChange, client.conf.ChannelBufferSize)
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if client.subscribers == nil {
+		close(ch)
+		return ch, func() {}
+	}
+	client.subscribers[ch] = none{}
+
+	return ch, func() {
+		client.lock.Lock()
+		defer client.lock.Unlock()
+
+		if _, ok := client.subscribers[ch]; ok {
+			delete(client.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
 func (client *client) Closed() bool {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -480,11 +548,179 @@ func (client *client) RefreshMetadata(topics ...string) error {
 		}
 	}
 
-	deadline := time.Time{}
-	if client.conf.Metadata.Timeout > 0 {
-		deadline = time.Now().Add(client.conf.Metadata.Timeout)
+	client.refreshLock.Lock()
+	waitFor, refresh := client.joinRefreshes(topics)
+	var delay time.Duration
+	if refresh != nil {
+		delay = client.refreshDelay(refresh.topics)
+	}
+	client.refreshLock.Unlock()
+
+	if refresh != nil {
+		client.runRefresh(refresh, delay)
+	}
+
+	for _, r := range waitFor {
+		<-r.done
+		if err := r.errFor(topics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metadataRefresh is a metadata request, shared by all the callers of
+// RefreshMetadata wanting the metadata of its topics until it is sent.
+type metadataRefresh struct {
+	topics    []string // empty when refreshing all topics
+	sent      bool     // set once the request is sent, after which no caller joins it
+	done      chan none
+	err       error            // the error failing the whole request
+	topicErrs map[string]error // the errors of single topics
+}
+
+// errFor returns the error of the refresh for a caller wanting the metadata of
+// topics, or of all topics if empty.
+func (r *metadataRefresh) errFor(topics []string) error {
+	if r.err != nil {
+		return r.err
 	}
-	return client.tryRefreshMetadata(topics, client.conf.Metadata.Retry.Max, deadline)
+	if len(topics) == 0 {
+		failed := make([]string, 0, len(r.topicErrs))
+		for topic := range r.topicErrs {
+			failed = append(failed, topic)
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		sort.Strings(failed)
+		return r.topicErrs[failed[0]]
+	}
+	for _, topic := range topics {
+		if err := r.topicErrs[topic]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *metadataRefresh) covers(topic string) bool {
+	if len(r.topics) == 0 {
+		return true
+	}
+	for _, t := range r.topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// joinRefreshes returns the refreshes not sent yet to wait for to get the
+// metadata of topics, including a new one for the topics that none of them
+// covers, which the caller must run. Refreshes already sent are not joined, as
+// their response may predate whatever made the caller refresh. You must hold
+// the refresh lock before calling this function.
+func (client *client) joinRefreshes(topics []string) (waitFor []*metadataRefresh, refresh *metadataRefresh) {
+	join := func(r *metadataRefresh) {
+		for _, joined := range waitFor {
+			if joined == r {
+				return
+			}
+		}
+		waitFor = append(waitFor, r)
+	}
+
+	var missing []string
+	if len(topics) == 0 {
+		for _, r := range client.refreshes {
+			if !r.sent && len(r.topics) == 0 {
+				return []*metadataRefresh{r}, nil
+			}
+		}
+	} else {
+	topicLoop:
+		for _, topic := range topics {
+			for _, r := range client.refreshes {
+				if !r.sent && r.covers(topic) {
+					join(r)
+					continue topicLoop
+				}
+			}
+			for _, t := range missing {
+				if t == topic {
+					continue topicLoop
+				}
+			}
+			missing = append(missing, topic)
+		}
+		if len(missing) == 0 {
+			return waitFor, nil
+		}
+	}
+
+	refresh = &metadataRefresh{topics: missing, done: make(chan none)}
+	client.refreshes = append(client.refreshes, refresh)
+	return append(waitFor, refresh), refresh
+}
+
+// refreshDelay returns how long to wait before refreshing the metadata of
+// topics, or of all topics if empty, to respect Metadata.MinRefreshInterval.
+// You must hold the refresh lock before calling this function.
+func (client *client) refreshDelay(topics []string) time.Duration {
+	last := client.fullRefreshedAt
+	for _, topic := range topics {
+		if t := client.refreshedAt[topic]; t.After(last) {
+			last = t
+		}
+	}
+	if last.IsZero() {
+		return 0
+	}
+	return time.Until(last.Add(client.conf.Metadata.MinRefreshInterval))
+}
+
+// runRefresh performs refresh after delay, then wakes up its waiters.
+func (client *client) runRefresh(refresh *metadataRefresh, delay time.Duration) {
+	if delay > 0 {
+		client.conf.logger().Debugf("client/metadata delaying refresh by %dms to respect the minimum refresh interval\n", delay/time.Millisecond)
+		select {
+		case <-time.After(delay):
+		case <-client.closer:
+		}
+	}
+
+	client.refreshLock.Lock()
+	refresh.sent = true
+	client.refreshLock.Unlock()
+
+	if client.Closed() {
+		refresh.err = ErrClosedClient
+	} else {
+		deadline := time.Time{}
+		if client.conf.Metadata.Timeout > 0 {
+			deadline = time.Now().Add(client.conf.Metadata.Timeout)
+		}
+		refresh.topicErrs, refresh.err = client.tryRefreshMetadata(refresh.topics, client.conf.Metadata.Retry.Max, deadline)
+	}
+
+	client.refreshLock.Lock()
+	defer client.refreshLock.Unlock()
+
+	for i, r := range client.refreshes {
+		if r == refresh {
+			client.refreshes = append(client.refreshes[:i], client.refreshes[i+1:]...)
+			break
+		}
+	}
+	now := time.Now()
+	if len(refresh.topics) == 0 {
+		client.fullRefreshedAt = now
+	}
+	for _, topic := range refresh.topics {
+		client.refreshedAt[topic] = now
+	}
+	close(refresh.done)
 }
 
 func (client *client) GetOffset(topic string, partitionID int32, time int64) (int64, error) {
@@ -504,6 +740,50 @@ func (client *client) GetOffset(topic string, partitionID int32, time int64) (in
 	return offset, err
 }
 
+func (client *client) GetOffsets(times map[string]map[int32]int64) (map[string]map[int32]*OffsetResult, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	// fetch metadata once for all the topics we don't know about yet
+	var unknown []string
+	for topic := range times {
+		if client.cachedPartitions(topic, allPartitions) == nil {
+			unknown = append(unknown, topic)
+		}
+	}
+	if len(unknown) > 0 {
+		if err := client.RefreshMetadata(unknown...); err != nil {
+			client.conf.logger().Warnf("client/offsets failed to refresh metadata for %v: %v\n", unknown, err)
+		}
+	}
+
+	results := make(map[string]map[int32]*OffsetResult, len(times))
+	pending := times
+	for attemptsRemaining := client.conf.Metadata.Retry.Max; ; attemptsRemaining-- {
+		retriable := client.getOffsets(pending, results)
+		if len(retriable) == 0 || attemptsRemaining <= 0 {
+			break
+		}
+
+		topics := make([]string, 0, len(retriable))
+		for topic := range retriable {
+			topics = append(topics, topic)
+		}
+		backoff := client.computeBackoff(attemptsRemaining)
+		client.conf.logger().Debugf("client/offsets retrying %d topics after %dms... (%d attempts remaining)\n", len(topics), backoff/time.Millisecond, attemptsRemaining)
+		if backoff > 0 {
+			time.Sleep(backoff)
+		}
+		if err := client.RefreshMetadata(topics...); err != nil {
+			break
+		}
+		pending = retriable
+	}
+
+	return results, nil
+}
+
 func (client *client) Controller() (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
@@ -600,6 +880,10 @@ func (client *client) RefreshCoordinator(consumerGroup string) error {
 // private broker management helpers
 
 func (client *client) randomizeSeedBrokers(addrs []string) {
+	if client.conf.Net.DNSLookup == DNSLookupResolveCanonicalBootstrapServersOnly {
+		addrs = client.conf.canonicalAddrs(addrs)
+	}
+
 	random := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for _, index := range random.Perm(len(addrs)) {
 		client.seedBrokers = append(client.seedBrokers, NewBroker(addrs[index]))
@@ -613,11 +897,11 @@ func (client *client) updateBroker(brokers []*Broker) {
 		currentBroker[broker.ID()] = broker
 		if client.brokers[broker.ID()] == nil { // add new broker
 			client.brokers[broker.ID()] = broker
-			Logger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
+			client.conf.logger().Debugf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 		} else if broker.Addr() != client.brokers[broker.ID()].Addr() { // replace broker with new address
 			safeAsyncClose(client.brokers[broker.ID()])
 			client.brokers[broker.ID()] = broker
-			Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
+			client.conf.logger().Debugf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
 		}
 	}
 
@@ -625,7 +909,7 @@ func (client *client) updateBroker(brokers []*Broker) {
 		if _, exist := currentBroker[id]; !exist { // remove old broker
 			safeAsyncClose(broker)
 			delete(client.brokers, id)
-			Logger.Printf("client/broker remove invalid broker #%d with %s", broker.ID(), broker.Addr())
+			client.conf.logger().Debugf("client/broker remove invalid broker #%d with %s", broker.ID(), broker.Addr())
 		}
 	}
 }
@@ -635,17 +919,17 @@ func (client *client) updateBroker(brokers []*Broker) {
 // or a previously registered Broker instance. You must hold the write lock before calling this function.
 func (client *client) registerBroker(broker *Broker) {
 	if client.brokers == nil {
-		Logger.Printf("cannot register broker #%d at %s, client already closed", broker.ID(), broker.Addr())
+		client.conf.logger().Warnf("cannot register broker #%d at %s, client already closed", broker.ID(), broker.Addr())
 		return
 	}
 
 	if client.brokers[broker.ID()] == nil {
 		client.brokers[broker.ID()] = broker
-		Logger.Printf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
+		client.conf.logger().Debugf("client/brokers registered new broker #%d at %s", broker.ID(), broker.Addr())
 	} else if broker.Addr() != client.brokers[broker.ID()].Addr() {
 		safeAsyncClose(client.brokers[broker.ID()])
 		client.brokers[broker.ID()] = broker
-		Logger.Printf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
+		client.conf.logger().Debugf("client/brokers replaced registered broker #%d with %s", broker.ID(), broker.Addr())
 	}
 }
 
@@ -663,7 +947,7 @@ func (client *client) deregisterBroker(broker *Broker) {
 		// but we really shouldn't have to; once that loop is made better this case can be
 		// removed, and the function generally can be renamed from `deregisterBroker` to
 		// `nextSeedBroker` or something
-		Logger.Printf("client/brokers deregistered broker #%d at %s", broker.ID(), broker.Addr())
+		client.conf.logger().Debugf("client/brokers deregistered broker #%d at %s", broker.ID(), broker.Addr())
 		delete(client.brokers, broker.ID())
 	}
 }
@@ -672,7 +956,7 @@ func (client *client) resurrectDeadBrokers() {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
-	Logger.Printf("client/brokers resurrecting %d dead seed brokers", len(client.deadSeeds))
+	client.conf.logger().Debugf("client/brokers resurrecting %d dead seed brokers", len(client.deadSeeds))
 	client.seedBrokers = append(client.seedBrokers, client.deadSeeds...)
 	client.deadSeeds = nil
 }
@@ -807,6 +1091,101 @@ func (client *client) getOffset(topic string, partitionID int32, time int64) (in
 	return block.Offsets[0], nil
 }
 
+// getOffsets sends a single OffsetRequest to the leader of each of the given
+// topic/partitions concurrently, storing the outcome in results. It returns the
+// topic/partitions that failed because of a leadership change and should be
+// retried once metadata has been refreshed.
+func (client *client) getOffsets(times map[string]map[int32]int64, results map[string]map[int32]*OffsetResult) map[string]map[int32]int64 {
+	setResult := func(topic string, partition int32, result *OffsetResult) {
+		if results[topic] == nil {
+			results[topic] = make(map[int32]*OffsetResult)
+		}
+		results[topic][partition] = result
+	}
+
+	retriable := make(map[string]map[int32]int64)
+	retry := func(topic string, partition int32, time int64, err error) {
+		setResult(topic, partition, &OffsetResult{Offset: -1, Err: err})
+		if retriable[topic] == nil {
+			retriable[topic] = make(map[int32]int64)
+		}
+		retriable[topic][partition] = time
+	}
+
+	requests := make(map[*Broker]*OffsetRequest)
+	for topic, partitions := range times {
+		for partition, time := range partitions {
+			broker, err := client.cachedLeader(topic, partition)
+			if err != nil {
+				retry(topic, partition, time, err)
+				continue
+			}
+
+			request := requests[broker]
+			if request == nil {
+				request = &OffsetRequest{}
+				if client.conf.Version.IsAtLeast(V0_10_1_0) {
+					request.Version = 1
+				}
+				requests[broker] = request
+			}
+			request.AddBlock(topic, partition, time, 1)
+		}
+	}
+
+	type brokerResponse struct {
+		broker   *Broker
+		request  *OffsetRequest
+		response *OffsetResponse
+		err      error
+	}
+	responses := make(chan *brokerResponse, len(requests))
+	for broker, request := range requests {
+		go func(broker *Broker, request *OffsetRequest) {
+			response, err := broker.GetAvailableOffsets(request)
+			responses <- &brokerResponse{broker: broker, request: request, response: response, err: err}
+		}(broker, request)
+	}
+
+	for range requests {
+		res := <-responses
+		if res.err != nil {
+			client.conf.logger().Warnf("client/offsets got error from broker %d while fetching offsets: %v\n", res.broker.ID(), res.err)
+			_ = res.broker.Close()
+		}
+
+		for topic, blocks := range res.request.blocks {
+			for partition, block := range blocks {
+				if res.err != nil {
+					retry(topic, partition, block.time, res.err)
+					continue
+				}
+
+				responseBlock := res.response.GetBlock(topic, partition)
+				if responseBlock == nil {
+					setResult(topic, partition, &OffsetResult{Offset: -1, Err: ErrIncompleteResponse})
+					continue
+				}
+
+				switch responseBlock.Err {
+				case ErrNoError:
+					result := &OffsetResult{Offset: -1, Timestamp: responseBlock.Timestamp}
+					if len(responseBlock.Offsets) == 1 {
+						result.Offset = responseBlock.Offsets[0]
+					}
+					setResult(topic, partition, result)
+				case ErrNotLeaderForPartition, ErrLeaderNotAvailable, ErrUnknownTopicOrPartition:
+					retry(topic, partition, block.time, responseBlock.Err)
+				default:
+					setResult(topic, partition, &OffsetResult{Offset: -1, Err: responseBlock.Err})
+				}
+			}
+		}
+	}
+
+	return retriable
+}
+
 // core metadata update logic
 
 func (client *client) backgroundMetadataUpdater() {
@@ -823,7 +1202,7 @@ func (client *client) backgroundMetadataUpdater() {
 		select {
 		case <-ticker.C:
 			if err := client.refreshMetadata(); err != nil {
-				Logger.Println("Client background metadata update:", err)
+				client.conf.logger().Warnf("Client background metadata update: %s", err)
 			}
 		case <-client.closer:
 			return
@@ -851,7 +1230,9 @@ func (client *client) refreshMetadata() error {
 	return nil
 }
 
-func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int, deadline time.Time) error {
+// tryRefreshMetadata returns the errors of single topics apart from the error
+// failing the whole refresh.
+func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int, deadline time.Time) (topicErrs map[string]error, err error) {
 	pastDeadline := func(backoff time.Duration) bool {
 		if !deadline.IsZero() && time.Now().Add(backoff).After(deadline) {
 			// we are past the deadline
@@ -859,30 +1240,30 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		}
 		return false
 	}
-	retry := func(err error) error {
+	retry := func(topicErrs map[string]error, err error) (map[string]error, error) {
 		if attemptsRemaining > 0 {
 			backoff := client.computeBackoff(attemptsRemaining)
 			if pastDeadline(backoff) {
-				Logger.Println("client/metadata skipping last retries as we would go past the metadata timeout")
-				return err
+				client.conf.logger().Warnf("client/metadata skipping last retries as we would go past the metadata timeout")
+				return topicErrs, err
 			}
-			Logger.Printf("client/metadata retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
+			client.conf.logger().Debugf("client/metadata retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
 			if backoff > 0 {
 				time.Sleep(backoff)
 			}
 			return client.tryRefreshMetadata(topics, attemptsRemaining-1, deadline)
 		}
-		return err
+		return topicErrs, err
 	}
 
 	broker := client.any()
 	for ; broker != nil && !pastDeadline(0); broker = client.any() {
-		allowAutoTopicCreation := true
+		allowAutoTopicCreation := client.conf.Metadata.AllowAutoTopicCreation
 		if len(topics) > 0 {
-			Logger.Printf("client/metadata fetching metadata for %v from broker %s\n", topics, broker.addr)
+			client.conf.logger().Debugf("client/metadata fetching metadata for %v from broker %s\n", topics, broker.addr)
 		} else {
 			allowAutoTopicCreation = false
-			Logger.Printf("client/metadata fetching metadata for all topics from broker %s\n", broker.addr)
+			client.conf.logger().Debugf("client/metadata fetching metadata for all topics from broker %s\n", broker.addr)
 		}
 
 		req := &MetadataRequest{Topics: topics, AllowAutoTopicCreation: allowAutoTopicCreation}
@@ -896,53 +1277,54 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int,
 		case nil:
 			allKnownMetaData := len(topics) == 0
 			// valid response, use it
-			shouldRetry, err := client.updateMetadata(response, allKnownMetaData)
+			shouldRetry, topicErrs := client.updateMetadata(response, allKnownMetaData)
 			if shouldRetry {
-				Logger.Println("client/metadata found some partitions to be leaderless")
-				return retry(err) // note: err can be nil
+				client.conf.logger().Debugf("client/metadata found some partitions to be leaderless")
+				return retry(topicErrs, nil)
 			}
-			return err
+			return topicErrs, nil
 
 		case PacketEncodingError:
 			// didn't even send, return the error
-			return err
+			return nil, err
 
 		case KError:
 			// if SASL auth error return as this _should_ be a non retryable err for all brokers
 			if err == ErrSASLAuthenticationFailed {
-				Logger.Println("client/metadata failed SASL authentication")
-				return err
+				client.conf.logger().Errorf("client/metadata failed SASL authentication")
+				return nil, err
 			}
 
 			if err == ErrTopicAuthorizationFailed {
-				Logger.Println("client is not authorized to access this topic. The topics were: ", topics)
-				return err
+				client.conf.logger().Errorf("client is not authorized to access this topic. The topics were: %v", topics)
+				return nil, err
 			}
 			// else remove that broker and try again
-			Logger.Printf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
+			client.conf.logger().Warnf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
 			_ = broker.Close()
 			client.deregisterBroker(broker)
 
 		default:
 			// some other error, remove that broker and try again
-			Logger.Printf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
+			client.conf.logger().Warnf("client/metadata got error from broker %d while fetching metadata: %v\n", broker.ID(), err)
 			_ = broker.Close()
 			client.deregisterBroker(broker)
 		}
 	}
 
 	if broker != nil {
-		Logger.Printf("client/metadata not fetching metadata from broker %s as we would go past the metadata timeout\n", broker.addr)
-		return retry(ErrOutOfBrokers)
+		client.conf.logger().Warnf("client/metadata not fetching metadata from broker %s as we would go past the metadata timeout\n", broker.addr)
+		return retry(nil, ErrOutOfBrokers)
 	}
 
-	Logger.Println("client/metadata no available broker to send metadata request to")
+	client.conf.logger().Errorf("client/metadata no available broker to send metadata request to")
 	client.resurrectDeadBrokers()
-	return retry(ErrOutOfBrokers)
+	return retry(nil, ErrOutOfBrokers)
 }
 
 // if no fatal error, returns a list of topics that need retrying due to ErrLeaderNotAvailable
-func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bool) (retry bool, err error) {
+// along with the errors of the topics which failed
+func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bool) (retry bool, topicErrs map[string]error) {
 	if client.Closed() {
 		return
 	}
@@ -950,6 +1332,14 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
+	var previous *metadataSnapshot
+	if len(client.subscribers) > 0 {
+		previous = client.snapshotMetadata()
+		defer func() {
+			client.notifyMetadataChanges(metadataChanges(previous, client.snapshotMetadata(), data, allKnownMetaData))
+		}()
+	}
+
 	// For all the brokers we received:
 	// - if it is a new ID, save it
 	// - if it is an existing ID, but the address we have is stale, discard the old one and save it
@@ -978,17 +1368,17 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 		case ErrNoError:
 			// no-op
 		case ErrInvalidTopic, ErrTopicAuthorizationFailed: // don't retry, don't store partial results
-			err = topic.Err
+			topicErrs = addTopicError(topicErrs, topic)
 			continue
 		case ErrUnknownTopicOrPartition: // retry, do not store partial partition results
-			err = topic.Err
+			topicErrs = addTopicError(topicErrs, topic)
 			retry = true
 			continue
 		case ErrLeaderNotAvailable: // retry, but store partial partition results
 			retry = true
 		default: // don't retry, don't store partial results
-			Logger.Printf("Unexpected topic-level metadata error: %s", topic.Err)
-			err = topic.Err
+			client.conf.logger().Warnf("Unexpected topic-level metadata error: %s", topic.Err)
+			topicErrs = addTopicError(topicErrs, topic)
 			continue
 		}
 
@@ -1009,6 +1399,29 @@ func (client *client) updateMetadata(data *MetadataResponse, allKnownMetaData bo
 	return
 }
 
+func addTopicError(topicErrs map[string]error, topic *TopicMetadata) map[string]error {
+	if topicErrs == nil {
+		topicErrs = make(map[string]error)
+	}
+	topicErrs[topic.Name] = topic.Err
+	return topicErrs
+}
+
+// notifyMetadataChanges sends changes to the subscribers, dropping them for
+// those which are not keeping up. You must hold the lock before calling this
+// function.
+func (client *client) notifyMetadataChanges(changes []MetadataChange) {
+	for ch := range client.subscribers {
+		for _, change := range changes {
+			select {
+			case ch <- change:
+			default:
+				client.conf.logger().Warnf("client/metadata dropping %s change, subscriber is not keeping up", change.Type)
+			}
+		}
+	}
+}
+
 func (client *client) cachedCoordinator(consumerGroup string) *Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
@@ -1038,7 +1451,7 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 	retry := func(err error) (*FindCoordinatorResponse, error) {
 		if attemptsRemaining > 0 {
 			backoff := client.computeBackoff(attemptsRemaining)
-			Logger.Printf("client/coordinator retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
+			client.conf.logger().Debugf("client/coordinator retrying after %dms... (%d attempts remaining)\n", backoff/time.Millisecond, attemptsRemaining)
 			time.Sleep(backoff)
 			return client.getConsumerMetadata(consumerGroup, attemptsRemaining-1)
 		}
@@ -1046,7 +1459,7 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 	}
 
 	for broker := client.any(); broker != nil; broker = client.any() {
-		Logger.Printf("client/coordinator requesting coordinator for consumergroup %s from %s\n", consumerGroup, broker.Addr())
+		client.conf.logger().Debugf("client/coordinator requesting coordinator for consumergroup %s from %s\n", consumerGroup, broker.Addr())
 
 		request := new(FindCoordinatorRequest)
 		request.CoordinatorKey = consumerGroup
@@ -1055,7 +1468,7 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 		response, err := broker.FindCoordinator(request)
 
 		if err != nil {
-			Logger.Printf("client/coordinator request to broker %s failed: %s\n", broker.Addr(), err)
+			client.conf.logger().Warnf("client/coordinator request to broker %s failed: %s\n", broker.Addr(), err)
 
 			switch err.(type) {
 			case PacketEncodingError:
@@ -1069,23 +1482,23 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 
 		switch response.Err {
 		case ErrNoError:
-			Logger.Printf("client/coordinator coordinator for consumergroup %s is #%d (%s)\n", consumerGroup, response.Coordinator.ID(), response.Coordinator.Addr())
+			client.conf.logger().Debugf("client/coordinator coordinator for consumergroup %s is #%d (%s)\n", consumerGroup, response.Coordinator.ID(), response.Coordinator.Addr())
 			return response, nil
 
 		case ErrConsumerCoordinatorNotAvailable:
-			Logger.Printf("client/coordinator coordinator for consumer group %s is not available\n", consumerGroup)
+			client.conf.logger().Debugf("client/coordinator coordinator for consumer group %s is not available\n", consumerGroup)
 
 			// This is very ugly, but this scenario will only happen once per cluster.
 			// The __consumer_offsets topic only has to be created one time.
 			// The number of partitions not configurable, but partition 0 should always exist.
 			if _, err := client.Leader("__consumer_offsets", 0); err != nil {
-				Logger.Printf("client/coordinator the __consumer_offsets topic is not initialized completely yet. Waiting 2 seconds...\n")
+				client.conf.logger().Debugf("client/coordinator the __consumer_offsets topic is not initialized completely yet. Waiting 2 seconds...\n")
 				time.Sleep(2 * time.Second)
 			}
 
 			return retry(ErrConsumerCoordinatorNotAvailable)
 		case ErrGroupAuthorizationFailed:
-			Logger.Printf("client was not authorized to access group %s while attempting to find coordinator", consumerGroup)
+			client.conf.logger().Errorf("client was not authorized to access group %s while attempting to find coordinator", consumerGroup)
 			return retry(ErrGroupAuthorizationFailed)
 
 		default:
@@ -1093,7 +1506,7 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 		}
 	}
 
-	Logger.Println("client/coordinator no available broker to send consumer metadata request to")
+	client.conf.logger().Errorf("client/coordinator no available broker to send consumer metadata request to")
 	client.resurrectDeadBrokers()
 	return retry(ErrOutOfBrokers)
 }
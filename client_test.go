@@ -3,6 +3,7 @@ package sarama
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -302,6 +303,70 @@ func TestClientMetadataWithOfflineReplicas(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientMetadataChanges(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker("localhost:1005", 5)
+	metadataResponse.AddBroker("localhost:1006", 6)
+	metadataResponse.AddTopicPartition("my_topic", 0, 5, []int32{5, 6}, []int32{5, 6}, []int32{}, ErrNoError)
+	metadataResponse.AddTopicPartition("other_topic", 0, 6, []int32{6}, []int32{6}, []int32{}, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, unsubscribe := client.SubscribeMetadataChanges()
+
+	metadataResponse = new(MetadataResponse)
+	metadataResponse.AddBroker("localhost:1005", 5)
+	metadataResponse.AddBroker("localhost:1007", 7)
+	metadataResponse.AddTopicPartition("my_topic", 0, 7, []int32{5, 6, 7}, []int32{5, 7}, []int32{}, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, 5, []int32{5, 7}, []int32{5, 7}, []int32{}, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	if err := client.RefreshMetadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []MetadataChange{
+		{Type: BrokerAdded, BrokerID: 7, Addr: "localhost:1007"},
+		{Type: BrokerRemoved, BrokerID: 6, Addr: "localhost:1006"},
+		{Type: PartitionsAdded, Topic: "my_topic", Partitions: []int32{1}},
+		{Type: LeaderChanged, Topic: "my_topic", Partition: 0, OldLeader: 5, NewLeader: 7},
+		{Type: ISRShrunk, Topic: "my_topic", Partition: 0, Replicas: []int32{6}},
+		{Type: TopicDeleted, Topic: "other_topic"},
+	}
+	for _, want := range expected {
+		select {
+		case got := <-changes:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected change %+v, got %+v", want, got)
+			}
+		default:
+			t.Fatalf("Expected change %+v, got none", want)
+		}
+	}
+	select {
+	case got := <-changes:
+		t.Errorf("Unexpected change %+v", got)
+	default:
+	}
+
+	unsubscribe()
+	if _, ok := <-changes; ok {
+		t.Error("Expected the channel to be closed after unsubscribing")
+	}
+	unsubscribe()
+
+	safeClose(t, client)
+}
+
 func TestClientGetOffset(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader := NewMockBroker(t, 2)
@@ -350,6 +415,73 @@ func TestClientGetOffset(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientGetOffsets(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader1 := NewMockBroker(t, 2)
+	leader2 := NewMockBroker(t, 3)
+
+	metadata := new(MetadataResponse)
+	metadata.AddTopicPartition("foo", 0, leader1.BrokerID(), nil, nil, nil, ErrNoError)
+	metadata.AddTopicPartition("foo", 1, leader2.BrokerID(), nil, nil, nil, ErrNoError)
+	metadata.AddTopicPartition("bar", 0, leader2.BrokerID(), nil, nil, nil, ErrNoError)
+	metadata.AddBroker(leader1.Addr(), leader1.BrokerID())
+	metadata.AddBroker(leader2.Addr(), leader2.BrokerID())
+	seedBroker.Returns(metadata)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Backoff = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsetResponse1 := new(OffsetResponse)
+	offsetResponse1.AddTopicPartition("foo", 0, 123)
+	leader1.Returns(offsetResponse1)
+
+	// foo/1 moved to leader1, bar/0 fails with a non retriable error
+	offsetResponse2 := new(OffsetResponse)
+	offsetResponse2.AddTopicPartition("foo", 1, -1)
+	offsetResponse2.Blocks["foo"][1].Err = ErrNotLeaderForPartition
+	offsetResponse2.AddTopicPartition("bar", 0, -1)
+	offsetResponse2.Blocks["bar"][0].Err = ErrTopicAuthorizationFailed
+	leader2.Returns(offsetResponse2)
+
+	movedMetadata := new(MetadataResponse)
+	movedMetadata.AddTopicPartition("foo", 0, leader1.BrokerID(), nil, nil, nil, ErrNoError)
+	movedMetadata.AddTopicPartition("foo", 1, leader1.BrokerID(), nil, nil, nil, ErrNoError)
+	movedMetadata.AddBroker(leader1.Addr(), leader1.BrokerID())
+	movedMetadata.AddBroker(leader2.Addr(), leader2.BrokerID())
+	seedBroker.Returns(movedMetadata)
+
+	offsetResponse3 := new(OffsetResponse)
+	offsetResponse3.AddTopicPartition("foo", 1, 456)
+	leader1.Returns(offsetResponse3)
+
+	results, err := client.GetOffsets(map[string]map[int32]int64{
+		"foo": {0: OffsetNewest, 1: OffsetNewest},
+		"bar": {0: OffsetOldest},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := results["foo"][0]; r.Err != nil || r.Offset != 123 {
+		t.Errorf("Unexpected result for foo/0, got %+v", r)
+	}
+	if r := results["foo"][1]; r.Err != nil || r.Offset != 456 {
+		t.Errorf("Unexpected result for foo/1, got %+v", r)
+	}
+	if r := results["bar"][0]; r.Err != ErrTopicAuthorizationFailed {
+		t.Errorf("Expected ErrTopicAuthorizationFailed for bar/0, got %+v", r)
+	}
+
+	seedBroker.Close()
+	leader1.Close()
+	leader2.Close()
+	safeClose(t, client)
+}
+
 func TestClientReceivingUnknownTopicWithBackoffFunc(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 
@@ -423,6 +555,39 @@ func TestClientReceivingUnknownTopic(t *testing.T) {
 	seedBroker.Close()
 }
 
+func TestClientMetadataAllowAutoTopicCreation(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V1_0_0_0
+	config.Metadata.AllowAutoTopicCreation = false
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	if err := client.RefreshMetadata("my_topic"); err != nil {
+		t.Fatal(err)
+	}
+
+	history := seedBroker.History()
+	req, ok := history[len(history)-1].Request.(*MetadataRequest)
+	if !ok || len(req.Topics) != 1 {
+		t.Fatalf("Expected a metadata request for my_topic, got %+v", history[len(history)-1].Request)
+	}
+	if req.AllowAutoTopicCreation {
+		t.Error("Expected the metadata request not to allow auto topic creation")
+	}
+}
+
 func TestClientReceivingPartialMetadata(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader := NewMockBroker(t, 5)
@@ -515,6 +680,145 @@ func TestClientRefreshBehaviour(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientRefreshMetadataCoalesced(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Metadata.MinRefreshInterval = 200 * time.Millisecond
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	metadataRequests := func() int {
+		n := 0
+		for _, rr := range seedBroker.History() {
+			if _, ok := rr.Request.(*MetadataRequest); ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.RefreshMetadata("my_topic"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := metadataRequests(); n != 2 {
+		t.Errorf("Expected the concurrent refreshes to share a single metadata request, got %d requests", n-1)
+	}
+
+	start := time.Now()
+	if err := client.RefreshMetadata("my_topic"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected the refresh to be delayed by the minimum refresh interval, took %s", elapsed)
+	}
+	if n := metadataRequests(); n != 3 {
+		t.Errorf("Expected 3 metadata requests, got %d", n)
+	}
+}
+
+func TestClientRefreshMetadataTopicErrors(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, seedBroker.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewTestConfig()
+	config.Metadata.Retry.Max = 0
+	config.Metadata.MinRefreshInterval = 200 * time.Millisecond
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	metadataResponse.AddTopic("typo", ErrUnknownTopicOrPartition)
+	seedBroker.Returns(metadataResponse)
+
+	// the refresh of my_topic joins the delayed refresh of both topics
+	typoErr := make(chan error)
+	go func() { typoErr <- client.RefreshMetadata("typo", "my_topic") }()
+	time.Sleep(50 * time.Millisecond)
+	if err := client.RefreshMetadata("my_topic"); err != nil {
+		t.Errorf("Expected no error for my_topic, got %v", err)
+	}
+	if err := <-typoErr; err != ErrUnknownTopicOrPartition {
+		t.Errorf("Expected ErrUnknownTopicOrPartition for typo, got %v", err)
+	}
+
+	n := 0
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*MetadataRequest); ok {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("Expected the refreshes to share a single metadata request, got %d requests", n-1)
+	}
+}
+
+func TestClientRefreshMetadataAfterSent(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()),
+	})
+
+	client, err := NewClient([]string{seedBroker.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	// the second refresh starts while the first one waits for its response,
+	// which may predate whatever made it refresh
+	seedBroker.SetLatency(200 * time.Millisecond)
+	firstErr := make(chan error)
+	go func() { firstErr <- client.RefreshMetadata("my_topic") }()
+	time.Sleep(50 * time.Millisecond)
+	if err := client.RefreshMetadata("my_topic"); err != nil {
+		t.Error(err)
+	}
+	if err := <-firstErr; err != nil {
+		t.Error(err)
+	}
+
+	n := 0
+	for _, rr := range seedBroker.History() {
+		if _, ok := rr.Request.(*MetadataRequest); ok {
+			n++
+		}
+	}
+	if n != 3 {
+		t.Errorf("Expected the second refresh to send its own metadata request, got %d requests", n-1)
+	}
+}
+
 func TestClientRefreshBrokers(t *testing.T) {
 	initialSeed := NewMockBroker(t, 0)
 	leader := NewMockBroker(t, 5)
@@ -40,6 +40,16 @@ type Config struct {
 		// sending on it blocks (default 5).
 		MaxOpenRequests int
 
+		// Whether to open dedicated connections to each broker for fetch,
+		// produce and group coordination requests (JoinGroup, SyncGroup,
+		// Heartbeat, LeaveGroup, OffsetCommit and OffsetFetch), besides the
+		// connection used for the other requests (defaults to false). Long
+		// polling fetches and large produce requests then do not delay
+		// heartbeats and metadata requests, at the cost of up to four
+		// connections per broker, each allowing MaxOpenRequests outstanding
+		// requests.
+		DedicatedConnections bool
+
 		// All three of the below configurations are similar to the
 		// `socket.timeout.ms` setting in JVM kafka. All of them default
 		// to 30 seconds.
@@ -57,7 +67,10 @@ type Config struct {
 		}
 
 		// SASL based authentication with broker. While there are multiple SASL authentication methods
-		// the current implementation is limited to plaintext (SASL/PLAIN) authentication
+		// the current implementation is limited to plaintext (SASL/PLAIN) authentication.
+		// With Version at least V2_2_0_0 and a SASL handshake v1, sessions whose lifetime is
+		// limited by the broker (connections.max.reauth.ms) are transparently re-authenticated
+		// on the same connection before they expire (KIP-368).
 		SASL struct {
 			// Whether or not to use SASL authentication when connecting to the broker
 			// (defaults to false).
@@ -101,6 +114,12 @@ type Config struct {
 		// If negative, keep-alives are disabled.
 		KeepAlive time.Duration
 
+		// DNSLookup controls how the host names of the brokers are resolved
+		// (defaults to DNSLookupDefault). Similar to `client.dns.lookup` in
+		// the JVM version. DNSLookupUseAllIPs is ignored when Proxy.Enable is
+		// set, so that the proxy resolves the host names.
+		DNSLookup ClientDNSLookup
+
 		// LocalAddr is the local address to use when dialing an
 		// address. The address must be of a compatible type for the
 		// network being dialed.
@@ -136,12 +155,27 @@ type Config struct {
 		// `topic.metadata.refresh.interval.ms` in the JVM version.
 		RefreshFrequency time.Duration
 
+		// The minimum time between two refreshes of the metadata of a topic
+		// (default 0, disabled). An earlier RefreshMetadata call is delayed
+		// until the interval has passed, and the calls for the same topics
+		// made meanwhile share its request. Similar to the way the JVM version
+		// uses `retry.backoff.ms` for metadata requests.
+		MinRefreshInterval time.Duration
+
 		// Whether to maintain a full set of metadata for all topics, or just
 		// the minimal set that has been necessary so far. The full set is simpler
 		// and usually more convenient, but can take up a substantial amount of
 		// memory if you have many topics and partitions. Defaults to true.
 		Full bool
 
+		// Whether to let the brokers create the topics requested when
+		// refreshing their metadata if they do not exist, when the brokers are
+		// configured with `auto.create.topics.enable`. Defaults to true. Brokers
+		// older than 0.11 always create them, and never do so when refreshing
+		// the metadata of all topics. Similar to `allow.auto.create.topics`
+		// in the JVM version.
+		AllowAutoTopicCreation bool
+
 		// How long to wait for a successful metadata response.
 		// Disabled by default which means a metadata request against an unreachable
 		// cluster (all brokers are unreachable or unresponsive) can take up to
@@ -180,6 +214,17 @@ type Config struct {
 		// If enabled, the producer will ensure that exactly one copy of each message is
 		// written.
 		Idempotent bool
+		// The upper bound on the time between a message being read from Input()
+		// and it being sent to the broker for the last time (defaults to 0 for
+		// no limit). Once it has elapsed, the message is failed with
+		// ErrDeliveryTimeout instead of being buffered, sent or retried,
+		// regardless of Retry.Max. It does not cover a request already in
+		// flight: such a request is not interrupted, and its messages are
+		// reported with its outcome up to Net.WriteTimeout plus
+		// Net.ReadTimeout later, or fail with ErrDeliveryTimeout if it needs
+		// to be retried. Similar to the `delivery.timeout.ms` setting of the
+		// JVM producer.
+		DeliveryTimeout time.Duration
 
 		// Return specifies what channels will be populated. If they are set to true,
 		// you must read from the respective channels to prevent deadlock. If,
@@ -216,6 +261,21 @@ type Config struct {
 			MaxMessages int
 		}
 
+		// Buffer bounds the memory held by the messages the producer has
+		// accepted but not yet delivered or failed, including the ones waiting
+		// to be retried.
+		Buffer struct {
+			// The total number of bytes of messages the producer may hold
+			// (defaults to 0 for unlimited). Similar to the `buffer.memory`
+			// setting of the JVM producer.
+			Memory int
+			// How long a send on Input() blocks waiting for buffer space when
+			// Memory is exhausted, after which the message is returned on
+			// Errors() with ErrBufferFull (defaults to 0, failing immediately).
+			// Similar to the `max.block.ms` setting of the JVM producer.
+			MaxBlock time.Duration
+		}
+
 		Retry struct {
 			// The total number of times to retry sending a message (default 3).
 			// Similar to the `message.send.max.retries` setting of the JVM producer.
@@ -288,6 +348,11 @@ type Config struct {
 				// coordinator for the group.
 				UserData []byte
 			}
+			Return struct {
+				// If enabled, the changes in the life-cycle of the member are
+				// returned on the Events channel (default disabled).
+				Events bool
+			}
 		}
 
 		Retry struct {
@@ -393,6 +458,12 @@ type Config struct {
 				// requests during OffsetManager shutdown (default 3).
 				Max int
 			}
+
+			// Store is an optional external store for consumed offsets. If set,
+			// the OffsetManager (and hence the ConsumerGroup) loads and commits
+			// offsets through it rather than through the group coordinator
+			// (defaults to nil, offsets are stored in Kafka).
+			Store OffsetStore
 		}
 
 		// IsolationLevel support 2 mode:
@@ -405,7 +476,10 @@ type Config struct {
 		// mutate the message before they are returned to the client.
 		// *ConsumerMessage modified by the first interceptor's OnConsume() is
 		// passed to the second interceptor OnConsume(), and so on in the
-		// interceptor chain.
+		// interceptor chain. Interceptors implementing
+		// ConsumerFilterInterceptor can drop messages, and those implementing
+		// ConsumerCommitInterceptor are notified of the offsets committed by
+		// an OffsetManager using this config.
 		Interceptors []ConsumerInterceptor
 	}
 
@@ -435,6 +509,11 @@ type Config struct {
 	// prior to starting Sarama.
 	// See Examples on how to use the metrics registry
 	MetricRegistry metrics.Registry
+	// Logger is the levelled logger the clients created with this config log
+	// to, with the broker, topic, partition, consumer group and member the
+	// messages relate to attached as fields. Defaults to nil, which writes
+	// every message to the global Logger.
+	Logger LevelledLogger
 }
 
 // NewConfig returns a new configuration instance with sane defaults.
@@ -456,6 +535,7 @@ func NewConfig() *Config {
 	c.Metadata.Retry.Backoff = 250 * time.Millisecond
 	c.Metadata.RefreshFrequency = 10 * time.Minute
 	c.Metadata.Full = true
+	c.Metadata.AllowAutoTopicCreation = true
 
 	c.Producer.MaxMessageBytes = 1000000
 	c.Producer.RequiredAcks = WaitForLocal
@@ -497,51 +577,51 @@ func NewConfig() *Config {
 func (c *Config) Validate() error {
 	// some configuration values should be warned on but not fail completely, do those first
 	if !c.Net.TLS.Enable && c.Net.TLS.Config != nil {
-		Logger.Println("Net.TLS is disabled but a non-nil configuration was provided.")
+		c.logger().Warnf("Net.TLS is disabled but a non-nil configuration was provided.")
 	}
 	if !c.Net.SASL.Enable {
 		if c.Net.SASL.User != "" {
-			Logger.Println("Net.SASL is disabled but a non-empty username was provided.")
+			c.logger().Warnf("Net.SASL is disabled but a non-empty username was provided.")
 		}
 		if c.Net.SASL.Password != "" {
-			Logger.Println("Net.SASL is disabled but a non-empty password was provided.")
+			c.logger().Warnf("Net.SASL is disabled but a non-empty password was provided.")
 		}
 	}
 	if c.Producer.RequiredAcks > 1 {
-		Logger.Println("Producer.RequiredAcks > 1 is deprecated and will raise an exception with kafka >= 0.8.2.0.")
+		c.logger().Warnf("Producer.RequiredAcks > 1 is deprecated and will raise an exception with kafka >= 0.8.2.0.")
 	}
 	if c.Producer.MaxMessageBytes >= int(MaxRequestSize) {
-		Logger.Println("Producer.MaxMessageBytes must be smaller than MaxRequestSize; it will be ignored.")
+		c.logger().Warnf("Producer.MaxMessageBytes must be smaller than MaxRequestSize; it will be ignored.")
 	}
 	if c.Producer.Flush.Bytes >= int(MaxRequestSize) {
-		Logger.Println("Producer.Flush.Bytes must be smaller than MaxRequestSize; it will be ignored.")
+		c.logger().Warnf("Producer.Flush.Bytes must be smaller than MaxRequestSize; it will be ignored.")
 	}
 	if (c.Producer.Flush.Bytes > 0 || c.Producer.Flush.Messages > 0) && c.Producer.Flush.Frequency == 0 {
-		Logger.Println("Producer.Flush: Bytes or Messages are set, but Frequency is not; messages may not get flushed.")
+		c.logger().Warnf("Producer.Flush: Bytes or Messages are set, but Frequency is not; messages may not get flushed.")
 	}
 	if c.Producer.Timeout%time.Millisecond != 0 {
-		Logger.Println("Producer.Timeout only supports millisecond resolution; nanoseconds will be truncated.")
+		c.logger().Warnf("Producer.Timeout only supports millisecond resolution; nanoseconds will be truncated.")
 	}
 	if c.Consumer.MaxWaitTime < 100*time.Millisecond {
-		Logger.Println("Consumer.MaxWaitTime is very low, which can cause high CPU and network usage. See documentation for details.")
+		c.logger().Warnf("Consumer.MaxWaitTime is very low, which can cause high CPU and network usage. See documentation for details.")
 	}
 	if c.Consumer.MaxWaitTime%time.Millisecond != 0 {
-		Logger.Println("Consumer.MaxWaitTime only supports millisecond precision; nanoseconds will be truncated.")
+		c.logger().Warnf("Consumer.MaxWaitTime only supports millisecond precision; nanoseconds will be truncated.")
 	}
 	if c.Consumer.Offsets.Retention%time.Millisecond != 0 {
-		Logger.Println("Consumer.Offsets.Retention only supports millisecond precision; nanoseconds will be truncated.")
+		c.logger().Warnf("Consumer.Offsets.Retention only supports millisecond precision; nanoseconds will be truncated.")
 	}
 	if c.Consumer.Group.Session.Timeout%time.Millisecond != 0 {
-		Logger.Println("Consumer.Group.Session.Timeout only supports millisecond precision; nanoseconds will be truncated.")
+		c.logger().Warnf("Consumer.Group.Session.Timeout only supports millisecond precision; nanoseconds will be truncated.")
 	}
 	if c.Consumer.Group.Heartbeat.Interval%time.Millisecond != 0 {
-		Logger.Println("Consumer.Group.Heartbeat.Interval only supports millisecond precision; nanoseconds will be truncated.")
+		c.logger().Warnf("Consumer.Group.Heartbeat.Interval only supports millisecond precision; nanoseconds will be truncated.")
 	}
 	if c.Consumer.Group.Rebalance.Timeout%time.Millisecond != 0 {
-		Logger.Println("Consumer.Group.Rebalance.Timeout only supports millisecond precision; nanoseconds will be truncated.")
+		c.logger().Warnf("Consumer.Group.Rebalance.Timeout only supports millisecond precision; nanoseconds will be truncated.")
 	}
 	if c.ClientID == defaultClientID {
-		Logger.Println("ClientID is the default of 'sarama', you should consider setting it to something application-specific.")
+		c.logger().Warnf("ClientID is the default of 'sarama', you should consider setting it to something application-specific.")
 	}
 
 	// validate Net values
@@ -554,6 +634,8 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Net.ReadTimeout must be > 0")
 	case c.Net.WriteTimeout <= 0:
 		return ConfigurationError("Net.WriteTimeout must be > 0")
+	case c.Net.DNSLookup < DNSLookupDefault || c.Net.DNSLookup > DNSLookupResolveCanonicalBootstrapServersOnly:
+		return ConfigurationError("Net.DNSLookup must be a valid ClientDNSLookup")
 	case c.Net.SASL.Enable:
 		if c.Net.SASL.Mechanism == "" {
 			c.Net.SASL.Mechanism = SASLTypePlaintext
@@ -629,6 +711,8 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Metadata.Retry.Backoff must be >= 0")
 	case c.Metadata.RefreshFrequency < 0:
 		return ConfigurationError("Metadata.RefreshFrequency must be >= 0")
+	case c.Metadata.MinRefreshInterval < 0:
+		return ConfigurationError("Metadata.MinRefreshInterval must be >= 0")
 	}
 
 	// validate the Producer values
@@ -655,6 +739,12 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Producer.Retry.Max must be >= 0")
 	case c.Producer.Retry.Backoff < 0:
 		return ConfigurationError("Producer.Retry.Backoff must be >= 0")
+	case c.Producer.DeliveryTimeout < 0:
+		return ConfigurationError("Producer.DeliveryTimeout must be >= 0")
+	case c.Producer.Buffer.Memory < 0:
+		return ConfigurationError("Producer.Buffer.Memory must be >= 0")
+	case c.Producer.Buffer.MaxBlock < 0:
+		return ConfigurationError("Producer.Buffer.MaxBlock must be >= 0")
 	}
 
 	if c.Producer.Compression == CompressionLZ4 && !c.Version.IsAtLeast(V0_10_0_0) {
@@ -713,7 +803,7 @@ func (c *Config) Validate() error {
 	}
 
 	if c.Consumer.Offsets.CommitInterval != 0 {
-		Logger.Println("Deprecation warning: Consumer.Offsets.CommitInterval exists for historical compatibility" +
+		c.logger().Warnf("Deprecation warning: Consumer.Offsets.CommitInterval exists for historical compatibility" +
 			" and should not be used. Please use Consumer.Offsets.AutoCommit, the current value will be ignored")
 	}
 
@@ -753,7 +843,7 @@ func (c *Config) Validate() error {
 
 func (c *Config) getDialer() proxy.Dialer {
 	if c.Net.Proxy.Enable {
-		Logger.Printf("using proxy %s", c.Net.Proxy.Dialer)
+		c.logger().Debugf("using proxy %s", c.Net.Proxy.Dialer)
 		return c.Net.Proxy.Dialer
 	} else {
 		return &net.Dialer{
@@ -67,6 +67,11 @@ func TestNetConfigValidates(t *testing.T) {
 				cfg.Net.WriteTimeout = 0
 			},
 			"Net.WriteTimeout must be > 0"},
+		{"DNSLookup",
+			func(cfg *Config) {
+				cfg.Net.DNSLookup = ClientDNSLookup(42)
+			},
+			"Net.DNSLookup must be a valid ClientDNSLookup"},
 		{"SASL.User",
 			func(cfg *Config) {
 				cfg.Net.SASL.Enable = true
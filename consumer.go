@@ -67,6 +67,18 @@ type Consumer interface {
 	// or OffsetOldest
 	ConsumePartition(topic string, partition int32, offset int64) (PartitionConsumer, error)
 
+	// ConsumePartitionsAt creates a PartitionConsumer on each of the given
+	// topic/partitions, starting at the earliest offset whose timestamp is greater
+	// than or equal to the given time. Partitions without any such message start
+	// at OffsetNewest. Offsets are resolved with one OffsetRequest per partition
+	// leader for the time and one for the log head offsets, which also initialise
+	// HighWaterMarkOffset. If any partition fails to start, the consumers created
+	// so far are closed and the error is returned. To reposition consumption, close
+	// the returned PartitionConsumers and call this method again. Requires Kafka
+	// 0.10.1 or higher for accurate results, older brokers resolve times at log
+	// segment granularity.
+	ConsumePartitionsAt(partitions map[string][]int32, t time.Time) (map[string]map[int32]PartitionConsumer, error)
+
 	// HighWaterMarks returns the current high water marks for each topic and partition.
 	// Consistency between partitions is not guaranteed since high water marks are updated separately.
 	HighWaterMarks() map[string]map[int32]int64
@@ -131,9 +143,53 @@ func (c *consumer) Partitions(topic string) ([]int32, error) {
 }
 
 func (c *consumer) ConsumePartition(topic string, partition int32, offset int64) (PartitionConsumer, error) {
-	child := &partitionConsumer{
+	child := c.newPartitionConsumer(topic, partition)
+
+	if err := child.chooseStartingOffset(offset); err != nil {
+		return nil, err
+	}
+
+	if err := c.startPartitionConsumer(child); err != nil {
+		return nil, err
+	}
+
+	return child, nil
+}
+
+func (c *consumer) ConsumePartitionsAt(partitions map[string][]int32, t time.Time) (map[string]map[int32]PartitionConsumer, error) {
+	offsets, newest, err := getOffsetsForTime(c.client, partitions, t)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[string]map[int32]PartitionConsumer, len(offsets))
+	for topic, partitionOffsets := range offsets {
+		children[topic] = make(map[int32]PartitionConsumer, len(partitionOffsets))
+		for partition, offset := range partitionOffsets {
+			child := c.newPartitionConsumer(topic, partition)
+			child.offset = offset
+			child.highWaterMarkOffset = newest[topic][partition]
+
+			if err := c.startPartitionConsumer(child); err != nil {
+				for _, started := range children {
+					for _, pc := range started {
+						_ = pc.Close()
+					}
+				}
+				return nil, err
+			}
+			children[topic][partition] = child
+		}
+	}
+
+	return children, nil
+}
+
+func (c *consumer) newPartitionConsumer(topic string, partition int32) *partitionConsumer {
+	return &partitionConsumer{
 		consumer:  c,
 		conf:      c.conf,
+		logger:    c.conf.logger(LogField{LogFieldTopic, topic}, LogField{LogFieldPartition, partition}),
 		topic:     topic,
 		partition: partition,
 		messages:  make(chan *ConsumerMessage, c.conf.ChannelBufferSize),
@@ -143,19 +199,16 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 		dying:     make(chan none),
 		fetchSize: c.conf.Consumer.Fetch.Default,
 	}
+}
 
-	if err := child.chooseStartingOffset(offset); err != nil {
-		return nil, err
-	}
-
-	var leader *Broker
-	var err error
-	if leader, err = c.client.Leader(child.topic, child.partition); err != nil {
-		return nil, err
+func (c *consumer) startPartitionConsumer(child *partitionConsumer) error {
+	leader, err := c.client.Leader(child.topic, child.partition)
+	if err != nil {
+		return err
 	}
 
 	if err := c.addChild(child); err != nil {
-		return nil, err
+		return err
 	}
 
 	go withRecover(child.dispatcher)
@@ -164,7 +217,61 @@ func (c *consumer) ConsumePartition(topic string, partition int32, offset int64)
 	child.broker = c.refBrokerConsumer(leader)
 	child.broker.input <- child
 
-	return child, nil
+	return nil
+}
+
+// getOffsetsForTime returns, for all the given topic/partitions, the earliest
+// offset whose timestamp is greater than or equal to t, or the log head offset
+// if there is no such message, as well as the log head offsets.
+func getOffsetsForTime(client Client, partitions map[string][]int32, t time.Time) (offsets, newest map[string]map[int32]int64, err error) {
+	offsets, err = getOffsets(client, partitions, t.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return nil, nil, err
+	}
+	newest, err = getOffsets(client, partitions, OffsetNewest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for topic, partitionOffsets := range offsets {
+		for partition, offset := range partitionOffsets {
+			if offset < 0 {
+				offsets[topic][partition] = newest[topic][partition]
+			}
+		}
+	}
+	return offsets, newest, nil
+}
+
+// getOffsets queries the offsets at the given time for all the given
+// topic/partitions using Client.GetOffsets, failing if any of them could not be
+// retrieved. Partitions without an offset for that time are reported as -1.
+func getOffsets(client Client, partitions map[string][]int32, time int64) (map[string]map[int32]int64, error) {
+	times := make(map[string]map[int32]int64, len(partitions))
+	for topic, topicPartitions := range partitions {
+		times[topic] = make(map[int32]int64, len(topicPartitions))
+		for _, partition := range topicPartitions {
+			times[topic][partition] = time
+		}
+	}
+
+	results, err := client.GetOffsets(times)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[string]map[int32]int64, len(results))
+	for topic, partitionResults := range results {
+		offsets[topic] = make(map[int32]int64, len(partitionResults))
+		for partition, result := range partitionResults {
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			offsets[topic][partition] = result.Offset
+		}
+	}
+
+	return offsets, nil
 }
 
 func (c *consumer) HighWaterMarks() map[string]map[int32]int64 {
@@ -298,6 +405,7 @@ type partitionConsumer struct {
 
 	consumer *consumer
 	conf     *Config
+	logger   fieldLogger
 	broker   *brokerConsumer
 	messages chan *ConsumerMessage
 	errors   chan *ConsumerError
@@ -327,7 +435,7 @@ func (child *partitionConsumer) sendError(err error) {
 	if child.conf.Consumer.Return.Errors {
 		child.errors <- cErr
 	} else {
-		Logger.Println(cErr)
+		child.logger.Errorf("%s", cErr)
 	}
 }
 
@@ -350,7 +458,7 @@ func (child *partitionConsumer) dispatcher() {
 				child.broker = nil
 			}
 
-			Logger.Printf("consumer/%s/%d finding new broker\n", child.topic, child.partition)
+			child.logger.Debugf("consumer/%s/%d finding new broker\n", child.topic, child.partition)
 			if err := child.dispatch(); err != nil {
 				child.sendError(err)
 				child.trigger <- none{}
@@ -454,6 +562,18 @@ func (child *partitionConsumer) HighWaterMarkOffset() int64 {
 	return atomic.LoadInt64(&child.highWaterMarkOffset)
 }
 
+// interceptMessages passes msgs through the chain of interceptors, returning
+// the ones which were not filtered out.
+func interceptMessages(msgs []*ConsumerMessage, interceptors []ConsumerInterceptor, logger fieldLogger) []*ConsumerMessage {
+	accepted := msgs[:0]
+	for _, msg := range msgs {
+		if msg.safelyApplyInterceptors(interceptors, logger) {
+			accepted = append(accepted, msg)
+		}
+	}
+	return accepted
+}
+
 func (child *partitionConsumer) responseFeeder() {
 	var msgs []*ConsumerMessage
 	expiryTicker := time.NewTicker(child.conf.Consumer.MaxProcessingTime)
@@ -467,10 +587,11 @@ feederLoop:
 			atomic.StoreInt32(&child.retries, 0)
 		}
 
+		if len(child.conf.Consumer.Interceptors) > 0 {
+			msgs = interceptMessages(msgs, child.conf.Consumer.Interceptors, child.logger)
+		}
+
 		for i, msg := range msgs {
-			for _, interceptor := range child.conf.Consumer.Interceptors {
-				msg.safelyApplyInterceptor(interceptor)
-			}
 		messageSelect:
 			select {
 			case <-child.dying:
@@ -584,7 +705,7 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 
 	// If request was throttled and empty we log and return without error
 	if response.ThrottleTime != time.Duration(0) && len(response.Blocks) == 0 {
-		Logger.Printf(
+		child.logger.Debugf(
 			"consumer/broker/%d FetchResponse throttled %v\n",
 			child.broker.broker.ID(), response.ThrottleTime)
 		return nil, nil
@@ -718,6 +839,7 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 type brokerConsumer struct {
 	consumer         *consumer
 	broker           *Broker
+	logger           fieldLogger
 	input            chan *partitionConsumer
 	newSubscriptions chan []*partitionConsumer
 	subscriptions    map[*partitionConsumer]none
@@ -730,6 +852,7 @@ func (c *consumer) newBrokerConsumer(broker *Broker) *brokerConsumer {
 	bc := &brokerConsumer{
 		consumer:         c,
 		broker:           broker,
+		logger:           c.conf.logger(LogField{LogFieldBroker, broker.ID()}),
 		input:            make(chan *partitionConsumer),
 		newSubscriptions: make(chan []*partitionConsumer),
 		wait:             make(chan none),
@@ -800,7 +923,7 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 		response, err := bc.fetchNewMessages()
 
 		if err != nil {
-			Logger.Printf("consumer/broker/%d disconnecting due to error processing FetchRequest: %s\n", bc.broker.ID(), err)
+			bc.logger.Warnf("consumer/broker/%d disconnecting due to error processing FetchRequest: %s\n", bc.broker.ID(), err)
 			bc.abort(err)
 			return
 		}
@@ -817,13 +940,13 @@ func (bc *brokerConsumer) subscriptionConsumer() {
 func (bc *brokerConsumer) updateSubscriptions(newSubscriptions []*partitionConsumer) {
 	for _, child := range newSubscriptions {
 		bc.subscriptions[child] = none{}
-		Logger.Printf("consumer/broker/%d added subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
+		bc.logger.Debugf("consumer/broker/%d added subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
 	}
 
 	for child := range bc.subscriptions {
 		select {
 		case <-child.dying:
-			Logger.Printf("consumer/broker/%d closed dead subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
+			bc.logger.Debugf("consumer/broker/%d closed dead subscription to %s/%d\n", bc.broker.ID(), child.topic, child.partition)
 			close(child.trigger)
 			delete(bc.subscriptions, child)
 		default:
@@ -852,26 +975,26 @@ func (bc *brokerConsumer) handleResponses() {
 
 		switch result {
 		case errTimedOut:
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because consuming was taking too long\n",
+			bc.logger.Warnf("consumer/broker/%d abandoned subscription to %s/%d because consuming was taking too long\n",
 				bc.broker.ID(), child.topic, child.partition)
 			delete(bc.subscriptions, child)
 		case ErrOffsetOutOfRange:
 			// there's no point in retrying this it will just fail the same way again
 			// shut it down and force the user to choose what to do
 			child.sendError(result)
-			Logger.Printf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, result)
+			child.logger.Errorf("consumer/%s/%d shutting down because %s\n", child.topic, child.partition, result)
 			close(child.trigger)
 			delete(bc.subscriptions, child)
 		case ErrUnknownTopicOrPartition, ErrNotLeaderForPartition, ErrLeaderNotAvailable, ErrReplicaNotAvailable:
 			// not an error, but does need redispatching
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
+			bc.logger.Debugf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
 				bc.broker.ID(), child.topic, child.partition, result)
 			child.trigger <- none{}
 			delete(bc.subscriptions, child)
 		default:
 			// dunno, tell the user and try redispatching
 			child.sendError(result)
-			Logger.Printf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
+			bc.logger.Warnf("consumer/broker/%d abandoned subscription to %s/%d because %s\n",
 				bc.broker.ID(), child.topic, child.partition, result)
 			child.trigger <- none{}
 			delete(bc.subscriptions, child)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"sync"
 	"time"
@@ -43,12 +44,48 @@ type ConsumerGroup interface {
 	// recreated to get the new claims.
 	Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error
 
+	// ConsumePattern behaves like Consume, but subscribes to all the topics
+	// whose name matches pattern, except for Kafka's internal topics. The
+	// topics are matched against the cluster metadata when the session starts,
+	// then every Config.Metadata.RefreshFrequency: when a matching topic is
+	// created or deleted, the session ends so that the next call to
+	// ConsumePattern rejoins the group with the new set of topics. The
+	// topics a session subscribed to are given by ConsumerGroupSession.Topics.
+	// It returns an error if no topic matches pattern.
+	ConsumePattern(ctx context.Context, pattern *regexp.Regexp, handler ConsumerGroupHandler) error
+
 	// Errors returns a read channel of errors that occurred during the consumer life-cycle.
 	// By default, errors are logged and not returned over this channel.
 	// If you want to implement any custom error handling, set your config's
 	// Consumer.Return.Errors setting to true, and read from this channel.
 	Errors() <-chan error
 
+	// Status returns a snapshot of the state of the member in the group, e.g.
+	// for readiness probes.
+	Status() ConsumerGroupStatus
+
+	// Events returns a read channel of the changes in the life-cycle of the
+	// member: joining the group, being assigned claims, having them revoked,
+	// leaving the group and failures. Events are only sent if the config's
+	// Consumer.Group.Return.Events setting is true, in which case you must
+	// read from this channel or events are dropped once it is full. It is
+	// closed by Close.
+	Events() <-chan *ConsumerGroupEvent
+
+	// Rejoin ends the current session gracefully, as a server-side rebalance
+	// would: the claims' Messages channels are closed, and once all
+	// ConsumeClaim functions exited Cleanup is called and the offsets are
+	// committed, then the member leaves the group and Consume returns.
+	// Calling Consume again joins the group as a new member, which makes the
+	// coordinator rebalance it. If the member is joining the group, the
+	// session ends as soon as it starts.
+	Rejoin() error
+
+	// SetMemberUserData replaces Config.Consumer.Group.Member.UserData for
+	// the next time the member joins the group, e.g. before calling Rejoin
+	// to have the BalanceStrategy take it into account.
+	SetMemberUserData(userData []byte)
+
 	// Close stops the ConsumerGroup and detaches any running sessions. It is required to call
 	// this function before the object passes out of scope, as it will otherwise leak memory.
 	Close() error
@@ -58,6 +95,7 @@ type consumerGroup struct {
 	client Client
 
 	config   *Config
+	logger   fieldLogger
 	consumer Consumer
 	groupID  string
 	memberID string
@@ -68,6 +106,28 @@ type consumerGroup struct {
 	closeOnce sync.Once
 
 	userData []byte
+
+	// ownedPartitions are the claims of the last session, which members of
+	// a StatefulBalanceStrategy report when joining with generationID.
+	ownedPartitions map[string][]int32
+	generationID    int32
+
+	// rebalanceReason is why the last session ended, protected by lock.
+	rebalanceReason string
+
+	// session is the running session, rejoinPending whether it should end as
+	// soon as it starts, leavePending whether the member should leave the
+	// group once it ended and memberUserData the user data sent when joining
+	// the group, protected by sessionLock.
+	sessionLock    sync.Mutex
+	session        *consumerGroupSession
+	rejoinPending  bool
+	leavePending   bool
+	memberUserData []byte
+
+	statusLock sync.Mutex
+	status     ConsumerGroupStatus
+	events     chan *ConsumerGroupEvent
 }
 
 // NewConsumerGroup creates a new consumer group the given broker addresses and configuration.
@@ -106,18 +166,84 @@ func newConsumerGroup(groupID string, client Client) (ConsumerGroup, error) {
 	}
 
 	return &consumerGroup{
-		client:   client,
-		consumer: consumer,
-		config:   config,
-		groupID:  groupID,
-		errors:   make(chan error, config.ChannelBufferSize),
-		closed:   make(chan none),
+		client:         client,
+		consumer:       consumer,
+		config:         config,
+		logger:         config.logger(LogField{LogFieldGroup, groupID}),
+		groupID:        groupID,
+		errors:         make(chan error, config.ChannelBufferSize),
+		events:         make(chan *ConsumerGroupEvent, config.ChannelBufferSize),
+		closed:         make(chan none),
+		memberUserData: config.Consumer.Group.Member.UserData,
+		generationID:   defaultGeneration,
 	}, nil
 }
 
 // Errors implements ConsumerGroup.
 func (c *consumerGroup) Errors() <-chan error { return c.errors }
 
+// Rejoin implements ConsumerGroup.
+func (c *consumerGroup) Rejoin() error {
+	select {
+	case <-c.closed:
+		return ErrClosedConsumerGroup
+	default:
+	}
+
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	if c.session == nil {
+		// end the session being joined as soon as it starts, otherwise the
+		// next call to Consume joins the group anyway
+		if c.Status().State == ConsumerGroupStateJoining {
+			c.rejoinPending = true
+			c.leavePending = true
+		}
+		return nil
+	}
+	c.leavePending = true
+	c.session.logger.Infof("rejoining the group on request")
+	c.session.end("rejoin requested")
+	return nil
+}
+
+// SetMemberUserData implements ConsumerGroup.
+func (c *consumerGroup) SetMemberUserData(userData []byte) {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+	c.memberUserData = userData
+}
+
+// setSession records sess as the running session, or nil if there is none,
+// ending it right away if a rejoin was requested while joining the group.
+func (c *consumerGroup) setSession(sess *consumerGroupSession) {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	c.session = sess
+	if c.rejoinPending {
+		c.rejoinPending = false
+		if sess != nil {
+			sess.end("rejoin requested")
+		} else {
+			// joining failed, so there is no session to leave the group after
+			c.leavePending = false
+		}
+	}
+}
+
+// takeLeavePending returns whether the member should leave the group after its
+// session ended, resetting it.
+func (c *consumerGroup) takeLeavePending() bool {
+	c.sessionLock.Lock()
+	defer c.sessionLock.Unlock()
+
+	leave := c.leavePending
+	c.leavePending = false
+	return leave
+}
+
 // Close implements ConsumerGroup.
 func (c *consumerGroup) Close() (err error) {
 	c.closeOnce.Do(func() {
@@ -127,6 +253,7 @@ func (c *consumerGroup) Close() (err error) {
 		if e := c.leave(); e != nil {
 			err = e
 		}
+		c.closeEvents()
 
 		// drain errors
 		go func() {
@@ -145,6 +272,24 @@ func (c *consumerGroup) Close() (err error) {
 
 // Consume implements ConsumerGroup.
 func (c *consumerGroup) Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error {
+	// Quick exit when no topics are provided
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics provided")
+	}
+
+	return c.consume(ctx, topics, nil, handler)
+}
+
+// ConsumePattern implements ConsumerGroup.
+func (c *consumerGroup) ConsumePattern(ctx context.Context, pattern *regexp.Regexp, handler ConsumerGroupHandler) error {
+	if pattern == nil {
+		return fmt.Errorf("no topic pattern provided")
+	}
+
+	return c.consume(ctx, nil, pattern, handler)
+}
+
+func (c *consumerGroup) consume(ctx context.Context, topics []string, pattern *regexp.Regexp, handler ConsumerGroupHandler) error {
 	// Ensure group is not closed
 	select {
 	case <-c.closed:
@@ -155,34 +300,65 @@ func (c *consumerGroup) Consume(ctx context.Context, topics []string, handler Co
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	// Quick exit when no topics are provided
-	if len(topics) == 0 {
-		return fmt.Errorf("no topics provided")
+	if pattern != nil {
+		// Resolve the topics matching the pattern from the metadata of all topics
+		var err error
+		if topics, err = c.matchTopics(pattern); err != nil {
+			return err
+		}
+		if len(topics) == 0 {
+			return fmt.Errorf("no topics match %s", pattern)
+		}
+	} else if err := c.client.RefreshMetadata(topics...); err != nil {
+		// Refresh metadata for requested topics
+		return err
 	}
 
-	// Refresh metadata for requested topics
-	if err := c.client.RefreshMetadata(topics...); err != nil {
-		return err
+	reason := c.rebalanceReason
+	if reason == "" {
+		reason = "initial join"
 	}
+	c.joining(reason)
 
 	// Init session
 	sess, err := c.newSession(ctx, topics, handler, c.config.Consumer.Group.Rebalance.Retry.Max)
-	if err == ErrClosedClient {
-		return ErrClosedConsumerGroup
-	} else if err != nil {
+	if err != nil {
+		c.setSession(nil)
+		c.joinFailed(err)
+		if err == ErrClosedClient {
+			return ErrClosedConsumerGroup
+		}
 		return err
 	}
+	// record the session before reporting the member stable, so that Rejoin
+	// always finds either the session or the Joining state
+	c.setSession(sess)
+	c.assigned(sess)
 
 	// loop check topic partition numbers changed
 	// will trigger rebalance when any topic partitions number had changed
 	// avoid Consume function called again that will generate more than loopCheckPartitionNumbers coroutine
-	go c.loopCheckPartitionNumbers(topics, sess)
+	go c.loopCheckPartitionNumbers(topics, pattern, sess)
 
 	// Wait for session exit signal
 	<-sess.ctx.Done()
+	c.rebalanceReason = sess.reason()
+	c.rebalancing()
 
 	// Gracefully release session claims
-	return sess.release(true)
+	err = sess.release(true)
+	c.setSession(nil)
+	c.revoked(sess)
+
+	// A follower joining again with the same member ID and metadata just gets
+	// its assignment back, so leave the group to rejoin it as a new member
+	if c.takeLeavePending() {
+		if e := c.leaveGroup(); e != nil {
+			c.handleError(e, "", -1)
+		}
+		c.memberID = ""
+	}
+	return err
 }
 
 func (c *consumerGroup) retryNewSession(ctx context.Context, topics []string, handler ConsumerGroupHandler, retries int, refreshCoordinator bool) (*consumerGroupSession, error) {
@@ -223,6 +399,7 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 		c.memberID = join.MemberId
 	case ErrUnknownMemberId, ErrIllegalGeneration: // reset member ID and retry immediately
 		c.memberID = ""
+		c.generationID = defaultGeneration
 		return c.newSession(ctx, topics, handler, retries)
 	case ErrNotCoordinatorForConsumer: // retry after backoff with coordinator refresh
 		if retries <= 0 {
@@ -248,7 +425,7 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 			return nil, err
 		}
 
-		plan, err = c.balance(members)
+		plan, err = c.balance(members, join.GenerationId)
 		if err != nil {
 			return nil, err
 		}
@@ -264,6 +441,7 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 	case ErrNoError:
 	case ErrUnknownMemberId, ErrIllegalGeneration: // reset member ID and retry immediately
 		c.memberID = ""
+		c.generationID = defaultGeneration
 		return c.newSession(ctx, topics, handler, retries)
 	case ErrNotCoordinatorForConsumer: // retry after backoff with coordinator refresh
 		if retries <= 0 {
@@ -282,21 +460,21 @@ func (c *consumerGroup) newSession(ctx context.Context, topics []string, handler
 	}
 
 	// Retrieve and sort claims
-	var claims map[string][]int32
+	assignment := new(ConsumerGroupMemberAssignment)
 	if len(groupRequest.MemberAssignment) > 0 {
-		members, err := groupRequest.GetMemberAssignment()
-		if err != nil {
+		if assignment, err = groupRequest.GetMemberAssignment(); err != nil {
 			return nil, err
 		}
-		claims = members.Topics
-		c.userData = members.UserData
+		c.userData = assignment.UserData
 
-		for _, partitions := range claims {
+		for _, partitions := range assignment.Topics {
 			sort.Sort(int32Slice(partitions))
 		}
 	}
+	c.ownedPartitions = assignment.Topics
+	c.generationID = join.GenerationId
 
-	return newConsumerGroupSession(ctx, c, claims, join.MemberId, join.GenerationId, handler)
+	return newConsumerGroupSession(ctx, c, topics, assignment, join.MemberId, join.GenerationId, handler)
 }
 
 func (c *consumerGroup) joinGroupRequest(coordinator *Broker, topics []string) (*JoinGroupResponse, error) {
@@ -312,7 +490,9 @@ func (c *consumerGroup) joinGroupRequest(coordinator *Broker, topics []string) (
 	}
 
 	// use static user-data if configured, otherwise use consumer-group userdata from the last sync
-	userData := c.config.Consumer.Group.Member.UserData
+	c.sessionLock.Lock()
+	userData := c.memberUserData
+	c.sessionLock.Unlock()
 	if len(userData) == 0 {
 		userData = c.userData
 	}
@@ -321,6 +501,18 @@ func (c *consumerGroup) joinGroupRequest(coordinator *Broker, topics []string) (
 		UserData: userData,
 	}
 	strategy := c.config.Consumer.Group.Rebalance.Strategy
+	if _, ok := strategy.(StatefulBalanceStrategy); ok {
+		// report the claims of the last session to the leader
+		meta.Version = 2
+		meta.GenerationID = c.generationID
+		meta.OwnedPartitions = make([]*OwnedPartition, 0, len(c.ownedPartitions))
+		for topic, partitions := range c.ownedPartitions {
+			meta.OwnedPartitions = append(meta.OwnedPartitions, &OwnedPartition{Topic: topic, Partitions: partitions})
+		}
+		sort.Slice(meta.OwnedPartitions, func(i, j int) bool {
+			return meta.OwnedPartitions[i].Topic < meta.OwnedPartitions[j].Topic
+		})
+	}
 	if err := req.AddGroupProtocolMetadata(strategy.Name(), meta); err != nil {
 		return nil, err
 	}
@@ -359,7 +551,7 @@ func (c *consumerGroup) heartbeatRequest(coordinator *Broker, memberID string, g
 	return coordinator.Heartbeat(req)
 }
 
-func (c *consumerGroup) balance(members map[string]ConsumerGroupMemberMetadata) (BalanceStrategyPlan, error) {
+func (c *consumerGroup) balance(members map[string]ConsumerGroupMemberMetadata, generationID int32) (BalanceStrategyPlan, error) {
 	topics := make(map[string][]int32)
 	for _, meta := range members {
 		for _, topic := range meta.Topics {
@@ -376,9 +568,31 @@ func (c *consumerGroup) balance(members map[string]ConsumerGroupMemberMetadata)
 	}
 
 	strategy := c.config.Consumer.Group.Rebalance.Strategy
+	if stateful, ok := strategy.(StatefulBalanceStrategy); ok {
+		return stateful.PlanWithPrevious(members, topics, previousAssignments(members), generationID)
+	}
 	return strategy.Plan(members, topics)
 }
 
+// previousAssignments returns the assignments the members report owning.
+func previousAssignments(members map[string]ConsumerGroupMemberMetadata) map[string]ConsumerGroupMemberAssignment {
+	previous := make(map[string]ConsumerGroupMemberAssignment, len(members))
+	for memberID, meta := range members {
+		if len(meta.OwnedPartitions) == 0 {
+			continue
+		}
+		assignment := ConsumerGroupMemberAssignment{
+			Topics:   make(map[string][]int32, len(meta.OwnedPartitions)),
+			UserData: meta.UserData,
+		}
+		for _, owned := range meta.OwnedPartitions {
+			assignment.Topics[owned.Topic] = append(assignment.Topics[owned.Topic], owned.Partitions...)
+		}
+		previous[memberID] = assignment
+	}
+	return previous
+}
+
 // Leaves the cluster, called by Close.
 func (c *consumerGroup) leave() error {
 	c.lock.Lock()
@@ -387,6 +601,16 @@ func (c *consumerGroup) leave() error {
 		return nil
 	}
 
+	if err := c.leaveGroup(); err != nil {
+		return err
+	}
+	c.left()
+	return nil
+}
+
+// leaveGroup sends the LeaveGroupRequest of the member. You must hold the lock
+// before calling this function.
+func (c *consumerGroup) leaveGroup() error {
 	coordinator, err := c.client.Coordinator(c.groupID)
 	if err != nil {
 		return err
@@ -414,6 +638,10 @@ func (c *consumerGroup) leave() error {
 }
 
 func (c *consumerGroup) handleError(err error, topic string, partition int32) {
+	if topic == "" {
+		c.errored(err)
+	}
+
 	if _, ok := err.(*ConsumerError); !ok && topic != "" && partition > -1 {
 		err = &ConsumerError{
 			Topic:     topic,
@@ -423,7 +651,7 @@ func (c *consumerGroup) handleError(err error, topic string, partition int32) {
 	}
 
 	if !c.config.Consumer.Return.Errors {
-		Logger.Println(err)
+		c.logger.Errorf("%s", err)
 		return
 	}
 
@@ -441,21 +669,24 @@ func (c *consumerGroup) handleError(err error, topic string, partition int32) {
 	}
 }
 
-func (c *consumerGroup) loopCheckPartitionNumbers(topics []string, session *consumerGroupSession) {
+func (c *consumerGroup) loopCheckPartitionNumbers(topics []string, pattern *regexp.Regexp, session *consumerGroupSession) {
 	pause := time.NewTicker(c.config.Metadata.RefreshFrequency)
 	defer session.cancel()
 	defer pause.Stop()
 	var oldTopicToPartitionNum map[string]int
 	var err error
 	if oldTopicToPartitionNum, err = c.topicToPartitionNumbers(topics); err != nil {
+		session.end(fmt.Sprintf("failed to get partitions: %s", err))
 		return
 	}
 	for {
 		if newTopicToPartitionNum, err := c.topicToPartitionNumbers(topics); err != nil {
+			session.end(fmt.Sprintf("failed to get partitions: %s", err))
 			return
 		} else {
 			for topic, num := range oldTopicToPartitionNum {
 				if newTopicToPartitionNum[topic] != num {
+					session.end(fmt.Sprintf("partitions of %s changed", topic))
 					return // trigger the end of the session on exit
 				}
 			}
@@ -463,20 +694,71 @@ func (c *consumerGroup) loopCheckPartitionNumbers(topics []string, session *cons
 		select {
 		case <-pause.C:
 		case <-session.ctx.Done():
-			Logger.Printf("loop check partition number coroutine will exit, topics %s", topics)
+			session.logger.Debugf("loop check partition number coroutine will exit, topics %s", topics)
 			// if session closed by other, should be exited
 			return
 		case <-c.closed:
 			return
 		}
+		// the topics were just matched to join the group, so they are only
+		// matched again from the first tick on
+		if pattern != nil {
+			if matched, err := c.matchTopics(pattern); err != nil {
+				session.end(fmt.Sprintf("failed to match topics: %s", err))
+				return
+			} else if !stringSlicesEqual(matched, topics) {
+				session.logger.Infof("topics matching %s changed from %v to %v, rejoining", pattern, topics, matched)
+				session.end(fmt.Sprintf("topics matching %s changed", pattern))
+				return // trigger the end of the session on exit
+			}
+		}
+	}
+}
+
+// matchTopics refreshes the metadata of all topics and returns the sorted
+// names of those matching pattern, internal topics excluded.
+func (c *consumerGroup) matchTopics(pattern *regexp.Regexp) ([]string, error) {
+	if err := c.client.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+
+	all, err := c.client.Topics()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, topic := range all {
+		if !isInternalTopic(topic) && pattern.MatchString(topic) {
+			matched = append(matched, topic)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+// isInternalTopic returns whether topic is used internally by Kafka.
+func isInternalTopic(topic string) bool {
+	return topic == "__consumer_offsets" || topic == "__transaction_state"
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
+	return true
 }
 
 func (c *consumerGroup) topicToPartitionNumbers(topics []string) (map[string]int, error) {
 	topicToPartitionNum := make(map[string]int, len(topics))
 	for _, topic := range topics {
 		if partitionNum, err := c.client.Partitions(topic); err != nil {
-			Logger.Printf("Consumer Group topic %s get partition number failed %v", topic, err)
+			c.logger.Warnf("Consumer Group topic %s get partition number failed %v", topic, err)
 			return nil, err
 		} else {
 			topicToPartitionNum[topic] = len(partitionNum)
@@ -492,6 +774,15 @@ type ConsumerGroupSession interface {
 	// Claims returns information about the claimed partitions by topic.
 	Claims() map[string][]int32
 
+	// Assignment returns the assignment of the member for this session,
+	// including the UserData set by the BalanceStrategy of the leader.
+	// Its Topics are the claims.
+	Assignment() *ConsumerGroupMemberAssignment
+
+	// Topics returns the topics the member subscribed to for this session,
+	// which are those matching the pattern given to ConsumePattern.
+	Topics() []string
+
 	// MemberID returns the cluster member ID.
 	MemberID() string
 
@@ -525,6 +816,17 @@ type ConsumerGroupSession interface {
 	// allows incrementing the offset. cf MarkOffset for more details.
 	ResetOffset(topic string, partition int32, offset int64, metadata string)
 
+	// ResetOffsetsToTime resets the offsets of all claimed partitions to the
+	// earliest offset whose timestamp is greater than or equal to the given time,
+	// or to the log head offset if there is no such message. The offsets are
+	// resolved with one OffsetRequest per partition leader for the time and one
+	// for the log head offsets, and replace the marked offsets, moving them backwards or forwards, including when
+	// none was committed yet. Claims being consumed are not repositioned, and
+	// marking offsets after the reset ones moves them forward again; commit
+	// and return from ConsumeClaim to resume consumption from the reset
+	// offsets in the next session.
+	ResetOffsetsToTime(t time.Time, metadata string) error
+
 	// MarkMessage marks a message as consumed.
 	MarkMessage(msg *ConsumerMessage, metadata string)
 
@@ -534,21 +836,29 @@ type ConsumerGroupSession interface {
 
 type consumerGroupSession struct {
 	parent       *consumerGroup
+	logger       fieldLogger
 	memberID     string
 	generationID int32
 	handler      ConsumerGroupHandler
 
-	claims  map[string][]int32
-	offsets *offsetManager
-	ctx     context.Context
-	cancel  func()
+	topics     []string
+	claims     map[string][]int32
+	assignment *ConsumerGroupMemberAssignment
+	offsets    *offsetManager
+	ctx        context.Context
+	cancel     func()
 
 	waitGroup       sync.WaitGroup
 	releaseOnce     sync.Once
 	hbDying, hbDead chan none
+
+	endLock   sync.Mutex
+	endReason string
 }
 
-func newConsumerGroupSession(ctx context.Context, parent *consumerGroup, claims map[string][]int32, memberID string, generationID int32, handler ConsumerGroupHandler) (*consumerGroupSession, error) {
+func newConsumerGroupSession(ctx context.Context, parent *consumerGroup, topics []string, assignment *ConsumerGroupMemberAssignment, memberID string, generationID int32, handler ConsumerGroupHandler) (*consumerGroupSession, error) {
+	claims := assignment.Topics
+
 	// init offset manager
 	offsets, err := newOffsetManagerFromClient(parent.groupID, memberID, generationID, parent.client)
 	if err != nil {
@@ -561,11 +871,14 @@ func newConsumerGroupSession(ctx context.Context, parent *consumerGroup, claims
 	// init session
 	sess := &consumerGroupSession{
 		parent:       parent,
+		logger:       parent.logger.With(LogField{LogFieldMember, memberID}),
 		memberID:     memberID,
 		generationID: generationID,
 		handler:      handler,
 		offsets:      offsets,
+		topics:       topics,
 		claims:       claims,
+		assignment:   assignment,
 		ctx:          ctx,
 		cancel:       cancel,
 		hbDying:      make(chan none),
@@ -609,7 +922,7 @@ func newConsumerGroupSession(ctx context.Context, parent *consumerGroup, claims
 
 				// cancel the as session as soon as the first
 				// goroutine exits
-				defer sess.cancel()
+				defer sess.end(fmt.Sprintf("ConsumeClaim of %s/%d returned", topic, partition))
 
 				// consume a single topic/partition, blocking
 				sess.consume(topic, partition)
@@ -620,9 +933,12 @@ func newConsumerGroupSession(ctx context.Context, parent *consumerGroup, claims
 }
 
 func (s *consumerGroupSession) Claims() map[string][]int32 { return s.claims }
+func (s *consumerGroupSession) Topics() []string           { return s.topics }
 func (s *consumerGroupSession) MemberID() string           { return s.memberID }
 func (s *consumerGroupSession) GenerationID() int32        { return s.generationID }
 
+func (s *consumerGroupSession) Assignment() *ConsumerGroupMemberAssignment { return s.assignment }
+
 func (s *consumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
 	if pom := s.offsets.findPOM(topic, partition); pom != nil {
 		pom.MarkOffset(offset, metadata)
@@ -639,6 +955,22 @@ func (s *consumerGroupSession) ResetOffset(topic string, partition int32, offset
 	}
 }
 
+func (s *consumerGroupSession) ResetOffsetsToTime(t time.Time, metadata string) error {
+	offsets, _, err := getOffsetsForTime(s.parent.client, s.claims, t)
+	if err != nil {
+		return err
+	}
+
+	for topic, partitionOffsets := range offsets {
+		for partition, offset := range partitionOffsets {
+			if pom := s.offsets.findPOM(topic, partition); pom != nil {
+				pom.setOffset(offset, metadata)
+			}
+		}
+	}
+	return nil
+}
+
 func (s *consumerGroupSession) MarkMessage(msg *ConsumerMessage, metadata string) {
 	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
 }
@@ -698,6 +1030,32 @@ func (s *consumerGroupSession) consume(topic string, partition int32) {
 	}
 }
 
+// end cancels the session, recording reason as why it ended unless it already
+// had.
+func (s *consumerGroupSession) end(reason string) {
+	s.endLock.Lock()
+	if s.endReason == "" && s.ctx.Err() == nil {
+		s.endReason = reason
+	}
+	s.endLock.Unlock()
+
+	s.cancel()
+}
+
+// reason returns why the session ended.
+func (s *consumerGroupSession) reason() string {
+	s.endLock.Lock()
+	defer s.endLock.Unlock()
+
+	if s.endReason != "" {
+		return s.endReason
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err.Error()
+	}
+	return "session released"
+}
+
 func (s *consumerGroupSession) release(withCleanup bool) (err error) {
 	// signal release, stop heartbeat
 	s.cancel()
@@ -738,6 +1096,7 @@ func (s *consumerGroupSession) heartbeatLoop() {
 		if err != nil {
 			if retries <= 0 {
 				s.parent.handleError(err, "", -1)
+				s.end(fmt.Sprintf("heartbeat failed: %s", err))
 				return
 			}
 
@@ -756,6 +1115,7 @@ func (s *consumerGroupSession) heartbeatLoop() {
 
 			if retries <= 0 {
 				s.parent.handleError(err, "", -1)
+				s.end(fmt.Sprintf("heartbeat failed: %s", err))
 				return
 			}
 
@@ -766,10 +1126,16 @@ func (s *consumerGroupSession) heartbeatLoop() {
 		switch resp.Err {
 		case ErrNoError:
 			retries = s.parent.config.Metadata.Retry.Max
-		case ErrRebalanceInProgress, ErrUnknownMemberId, ErrIllegalGeneration:
+			s.parent.heartbeated()
+		case ErrRebalanceInProgress:
+			s.end("rebalance in progress")
+			return
+		case ErrUnknownMemberId, ErrIllegalGeneration:
+			s.end(fmt.Sprintf("heartbeat rejected: %s", resp.Err))
 			return
 		default:
 			s.parent.handleError(resp.Err, "", -1)
+			s.end(fmt.Sprintf("heartbeat failed: %s", resp.Err))
 			return
 		}
 
@@ -5,6 +5,21 @@ type ConsumerGroupMemberMetadata struct {
 	Version  int16
 	Topics   []string
 	UserData []byte
+
+	// OwnedPartitions are the partitions the member was assigned in the
+	// generation GenerationID, as reported when joining the group from
+	// versions 1 and 2 respectively. Version 1 metadata without
+	// OwnedPartitions is encoded without them, as older versions of sarama
+	// expect. GenerationID is zero if not reported.
+	OwnedPartitions []*OwnedPartition
+	GenerationID    int32
+}
+
+// OwnedPartition is a topic and the partitions of it a member of a consumer
+// group owned.
+type OwnedPartition struct {
+	Topic      string
+	Partitions []int32
 }
 
 func (m *ConsumerGroupMemberMetadata) encode(pe packetEncoder) error {
@@ -18,6 +33,24 @@ func (m *ConsumerGroupMemberMetadata) encode(pe packetEncoder) error {
 		return err
 	}
 
+	if m.Version >= 2 || (m.Version >= 1 && m.OwnedPartitions != nil) {
+		if err := pe.putArrayLength(len(m.OwnedPartitions)); err != nil {
+			return err
+		}
+		for _, owned := range m.OwnedPartitions {
+			if err := pe.putString(owned.Topic); err != nil {
+				return err
+			}
+			if err := pe.putInt32Array(owned.Partitions); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.Version >= 2 {
+		pe.putInt32(m.GenerationID)
+	}
+
 	return nil
 }
 
@@ -34,6 +67,30 @@ func (m *ConsumerGroupMemberMetadata) decode(pd packetDecoder) (err error) {
 		return
 	}
 
+	if m.Version >= 1 && pd.remaining() > 0 {
+		var n int
+		if n, err = pd.getArrayLength(); err != nil {
+			return
+		}
+		m.OwnedPartitions = make([]*OwnedPartition, n)
+		for i := range m.OwnedPartitions {
+			owned := new(OwnedPartition)
+			if owned.Topic, err = pd.getString(); err != nil {
+				return
+			}
+			if owned.Partitions, err = pd.getInt32Array(); err != nil {
+				return
+			}
+			m.OwnedPartitions[i] = owned
+		}
+	}
+
+	if m.Version >= 2 && pd.remaining() > 0 {
+		if m.GenerationID, err = pd.getInt32(); err != nil {
+			return
+		}
+	}
+
 	return nil
 }
 
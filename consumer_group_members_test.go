@@ -14,6 +14,16 @@ var (
 		0, 3, 't', 'w', 'o', // Topic two
 		0, 0, 0, 3, 0x01, 0x02, 0x03, // Userdata
 	}
+	groupMemberMetadataV2 = []byte{
+		0, 2, // Version
+		0, 0, 0, 1, // Topic array length
+		0, 3, 'o', 'n', 'e', // Topic one
+		0, 0, 0, 3, 0x01, 0x02, 0x03, // Userdata
+		0, 0, 0, 1, // OwnedPartitions array length
+		0, 3, 'o', 'n', 'e', // Topic one
+		0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 3, // 1, 3
+		0, 0, 0, 5, // GenerationID
+	}
 	groupMemberAssignment = []byte{
 		0, 1, // Version
 		0, 0, 0, 1, // Topic array length
@@ -47,6 +57,31 @@ func TestConsumerGroupMemberMetadata(t *testing.T) {
 	}
 }
 
+func TestConsumerGroupMemberMetadataV2(t *testing.T) {
+	meta := &ConsumerGroupMemberMetadata{
+		Version:         2,
+		Topics:          []string{"one"},
+		UserData:        []byte{0x01, 0x02, 0x03},
+		OwnedPartitions: []*OwnedPartition{{Topic: "one", Partitions: []int32{1, 3}}},
+		GenerationID:    5,
+	}
+
+	buf, err := encode(meta, nil)
+	if err != nil {
+		t.Error("Failed to encode data", err)
+	} else if !bytes.Equal(groupMemberMetadataV2, buf) {
+		t.Errorf("Encoded data does not match expectation\nexpected: %v\nactual: %v", groupMemberMetadataV2, buf)
+	}
+
+	meta2 := new(ConsumerGroupMemberMetadata)
+	err = decode(buf, meta2)
+	if err != nil {
+		t.Error("Failed to decode data", err)
+	} else if !reflect.DeepEqual(meta, meta2) {
+		t.Errorf("Encoded data does not match expectation\nexpected: %v\nactual: %v", meta, meta2)
+	}
+}
+
 func TestConsumerGroupMemberAssignment(t *testing.T) {
 	amt := &ConsumerGroupMemberAssignment{
 		Version: 1,
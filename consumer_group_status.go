@@ -0,0 +1,246 @@
+package sarama
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConsumerGroupState is the state of the member of a ConsumerGroup.
+type ConsumerGroupState int8
+
+const (
+	// ConsumerGroupStateIdle is the state of a member which is not consuming,
+	// before the first call to Consume and between sessions.
+	ConsumerGroupStateIdle ConsumerGroupState = iota
+	// ConsumerGroupStateJoining is the state of a member joining and syncing
+	// the group, including while retrying after a failure.
+	ConsumerGroupStateJoining
+	// ConsumerGroupStateStable is the state of a member consuming its claims.
+	ConsumerGroupStateStable
+	// ConsumerGroupStateRebalancing is the state of a member whose session
+	// ended, while it waits for its ConsumeClaim functions to exit, runs
+	// Cleanup and commits its offsets before releasing its claims.
+	ConsumerGroupStateRebalancing
+	// ConsumerGroupStateClosed is the state of a member once the group is
+	// closed.
+	ConsumerGroupStateClosed
+)
+
+func (s ConsumerGroupState) String() string {
+	switch s {
+	case ConsumerGroupStateIdle:
+		return "Idle"
+	case ConsumerGroupStateJoining:
+		return "Joining"
+	case ConsumerGroupStateStable:
+		return "Stable"
+	case ConsumerGroupStateRebalancing:
+		return "Rebalancing"
+	case ConsumerGroupStateClosed:
+		return "Closed"
+	default:
+		return fmt.Sprintf("ConsumerGroupState(%d)", int8(s))
+	}
+}
+
+// ConsumerGroupStatus is a snapshot of the state of the member of a
+// ConsumerGroup, as returned by ConsumerGroup.Status.
+type ConsumerGroupStatus struct {
+	State ConsumerGroupState
+
+	// MemberID and GenerationID identify the member in the group since it
+	// last joined it. They are kept until the member leaves the group.
+	MemberID     string
+	GenerationID int32
+
+	// Claims are the partitions of the current session by topic, or nil
+	// outside of a session.
+	Claims map[string][]int32
+
+	// LastHeartbeat is the time of the last successful heartbeat, or zero if
+	// none succeeded yet.
+	LastHeartbeat time.Time
+
+	// LastRebalance is the time the member last started to join the group,
+	// and LastRebalanceReason why, e.g. "initial join", "rebalance in
+	// progress" or "partitions of my-topic changed".
+	LastRebalance       time.Time
+	LastRebalanceReason string
+}
+
+// ConsumerGroupEventType is the kind of a ConsumerGroupEvent.
+type ConsumerGroupEventType int8
+
+const (
+	// ConsumerGroupJoining is sent when the member starts to join the group.
+	ConsumerGroupJoining ConsumerGroupEventType = iota
+	// ConsumerGroupAssigned is sent when a session starts with the claims
+	// assigned to the member.
+	ConsumerGroupAssigned
+	// ConsumerGroupRevoked is sent when the claims of a session have been
+	// released.
+	ConsumerGroupRevoked
+	// ConsumerGroupLeft is sent when the member left the group on Close.
+	ConsumerGroupLeft
+	// ConsumerGroupErrored is sent when joining the group, heartbeating or
+	// releasing a session fails.
+	ConsumerGroupErrored
+)
+
+func (t ConsumerGroupEventType) String() string {
+	switch t {
+	case ConsumerGroupJoining:
+		return "Joining"
+	case ConsumerGroupAssigned:
+		return "Assigned"
+	case ConsumerGroupRevoked:
+		return "Revoked"
+	case ConsumerGroupLeft:
+		return "Left"
+	case ConsumerGroupErrored:
+		return "Errored"
+	default:
+		return fmt.Sprintf("ConsumerGroupEventType(%d)", int8(t))
+	}
+}
+
+// ConsumerGroupEvent is a change in the life-cycle of the member of a
+// ConsumerGroup, returned on ConsumerGroup.Events.
+type ConsumerGroupEvent struct {
+	Type ConsumerGroupEventType
+	Time time.Time
+
+	MemberID     string
+	GenerationID int32
+
+	// Claims are the partitions assigned by Assigned events and released by
+	// Revoked events.
+	Claims map[string][]int32
+
+	// Reason is why the member is joining the group for Joining events.
+	Reason string
+
+	// Err is the error of Errored events.
+	Err error
+}
+
+// Status implements ConsumerGroup.
+func (c *consumerGroup) Status() ConsumerGroupStatus {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+
+	status := c.status
+	status.Claims = copyClaims(c.status.Claims)
+	return status
+}
+
+// Events implements ConsumerGroup.
+func (c *consumerGroup) Events() <-chan *ConsumerGroupEvent { return c.events }
+
+// updateStatus applies update to the status of the member, then sends event,
+// if not nil, filled in with the updated status. Updates are ignored once the
+// group is closed.
+func (c *consumerGroup) updateStatus(update func(*ConsumerGroupStatus), event *ConsumerGroupEvent) {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+
+	if c.status.State == ConsumerGroupStateClosed {
+		return
+	}
+	update(&c.status)
+	if event == nil || !c.config.Consumer.Group.Return.Events {
+		return
+	}
+
+	event.Time = time.Now()
+	event.MemberID = c.status.MemberID
+	event.GenerationID = c.status.GenerationID
+	event.Claims = copyClaims(event.Claims)
+	select {
+	case c.events <- event:
+	default:
+		c.logger.Warnf("Dropped %s event as the events channel is full", event.Type)
+	}
+}
+
+// joining records that the member starts to join the group for reason.
+func (c *consumerGroup) joining(reason string) {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.State = ConsumerGroupStateJoining
+		s.Claims = nil
+		s.LastRebalance = time.Now()
+		s.LastRebalanceReason = reason
+	}, &ConsumerGroupEvent{Type: ConsumerGroupJoining, Reason: reason})
+}
+
+// assigned records the start of sess.
+func (c *consumerGroup) assigned(sess *consumerGroupSession) {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.State = ConsumerGroupStateStable
+		s.MemberID = sess.memberID
+		s.GenerationID = sess.generationID
+		s.Claims = copyClaims(sess.claims)
+	}, &ConsumerGroupEvent{Type: ConsumerGroupAssigned, Claims: sess.claims})
+}
+
+// rebalancing records the end of the current session.
+func (c *consumerGroup) rebalancing() {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.State = ConsumerGroupStateRebalancing
+	}, nil)
+}
+
+// revoked records the release of the claims of sess.
+func (c *consumerGroup) revoked(sess *consumerGroupSession) {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.State = ConsumerGroupStateIdle
+		s.Claims = nil
+	}, &ConsumerGroupEvent{Type: ConsumerGroupRevoked, Claims: sess.claims})
+}
+
+// errored records a failure of the member to stay in the group or to release
+// its claims.
+func (c *consumerGroup) errored(err error) {
+	c.updateStatus(func(*ConsumerGroupStatus) {}, &ConsumerGroupEvent{Type: ConsumerGroupErrored, Err: err})
+}
+
+// joinFailed records a failure of the member to join the group.
+func (c *consumerGroup) joinFailed(err error) {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.State = ConsumerGroupStateIdle
+	}, &ConsumerGroupEvent{Type: ConsumerGroupErrored, Err: err})
+}
+
+// left records that the member left the group.
+func (c *consumerGroup) left() {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.Claims = nil
+	}, &ConsumerGroupEvent{Type: ConsumerGroupLeft})
+}
+
+// heartbeated records a successful heartbeat.
+func (c *consumerGroup) heartbeated() {
+	c.updateStatus(func(s *ConsumerGroupStatus) {
+		s.LastHeartbeat = time.Now()
+	}, nil)
+}
+
+// closeEvents records that the group is closed and closes the events channel.
+func (c *consumerGroup) closeEvents() {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	c.status.State = ConsumerGroupStateClosed
+	c.status.Claims = nil
+	close(c.events)
+}
+
+func copyClaims(claims map[string][]int32) map[string][]int32 {
+	if claims == nil {
+		return nil
+	}
+	dup := make(map[string][]int32, len(claims))
+	for topic, partitions := range claims {
+		dup[topic] = append([]int32(nil), partitions...)
+	}
+	return dup
+}
@@ -3,6 +3,10 @@ package sarama
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
 )
 
 type exampleConsumerGroupHandler struct{}
@@ -50,3 +54,588 @@ func ExampleConsumerGroup() {
 		}
 	}
 }
+
+func TestConsumerGroupMatchTopics(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	metadata := NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetLeader("tenant-b", 0, broker.BrokerID()).
+		SetLeader("tenant-a", 0, broker.BrokerID()).
+		SetLeader("other", 0, broker.BrokerID()).
+		SetLeader("__consumer_offsets", 0, broker.BrokerID())
+	broker.SetHandlerByMap(map[string]MockResponse{"MetadataRequest": metadata})
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	topics, err := group.(*consumerGroup).matchTopics(regexp.MustCompile("^tenant-|offsets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"tenant-a", "tenant-b"}; !reflect.DeepEqual(topics, expected) {
+		t.Errorf("Expected topics %v, got %v", expected, topics)
+	}
+
+	// a topic created after the subscription is matched on the next check
+	metadata.SetLeader("tenant-c", 0, broker.BrokerID())
+	topics, err = group.(*consumerGroup).matchTopics(regexp.MustCompile("^tenant-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"tenant-a", "tenant-b", "tenant-c"}; !reflect.DeepEqual(topics, expected) {
+		t.Errorf("Expected topics %v, got %v", expected, topics)
+	}
+}
+
+func TestConsumerGroupConsumePattern(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(consumerGroupMockHandlers(t, broker))
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	handler := sessionConsumerGroupHandler{claimed: make(chan none, 1)}
+	if err := group.ConsumePattern(context.Background(), regexp.MustCompile("^other-"), handler); err == nil {
+		t.Error("Expected an error when no topic matches the pattern")
+	}
+
+	fullRefreshes := func() (n int) {
+		for _, rr := range broker.History() {
+			if req, ok := rr.Request.(*MetadataRequest); ok && req.Topics == nil {
+				n++
+			}
+		}
+		return n
+	}
+	before := fullRefreshes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- group.ConsumePattern(ctx, regexp.MustCompile("^my-"), handler)
+	}()
+	<-handler.claimed
+	time.Sleep(100 * time.Millisecond)
+
+	// the topics matched to join the group are not matched again right away
+	if n := fullRefreshes() - before; n != 1 {
+		t.Errorf("Expected a single refresh of all topics at session start, got %d", n)
+	}
+
+	cancel()
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// consumerGroupMockHandlers returns the responses of broker as the coordinator
+// of my-group for a single member, "my-member", consuming my-topic/0.
+func consumerGroupMockHandlers(t *testing.T, broker *MockBroker) map[string]MockResponse {
+	return map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my-topic", 0, broker.BrokerID()),
+		"FindCoordinatorRequest": NewMockFindCoordinatorResponse(t).
+			SetCoordinator(CoordinatorGroup, "my-group", broker),
+		"JoinGroupRequest": NewMockJoinGroupResponse(t).
+			SetGenerationId(3).
+			SetLeaderId("my-member").
+			SetMemberId("my-member").
+			SetMember("my-member", &ConsumerGroupMemberMetadata{Topics: []string{"my-topic"}}),
+		"SyncGroupRequest": NewMockSyncGroupResponse(t).
+			SetMemberAssignment(&ConsumerGroupMemberAssignment{Topics: map[string][]int32{"my-topic": {0}}}),
+		"HeartbeatRequest": NewMockHeartbeatResponse(t),
+		"OffsetFetchRequest": NewMockOffsetFetchResponse(t).
+			SetOffset("my-group", "my-topic", 0, 0, "", ErrNoError),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my-topic", 0, OffsetOldest, 0).
+			SetOffset("my-topic", 0, OffsetNewest, 0),
+		"FetchRequest":        NewMockFetchResponse(t, 1),
+		"OffsetCommitRequest": NewMockOffsetCommitResponse(t),
+		"LeaveGroupRequest":   NewMockLeaveGroupResponse(t),
+	}
+}
+
+func expectConsumerGroupEvent(t *testing.T, group ConsumerGroup, typ ConsumerGroupEventType) *ConsumerGroupEvent {
+	t.Helper()
+	select {
+	case event := <-group.Events():
+		if event.Type != typ {
+			t.Fatalf("Expected %s event, got %s", typ, event.Type)
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s event", typ)
+		return nil
+	}
+}
+
+type sessionConsumerGroupHandler struct {
+	exampleConsumerGroupHandler
+	claimed chan none
+}
+
+func (h sessionConsumerGroupHandler) ConsumeClaim(sess ConsumerGroupSession, claim ConsumerGroupClaim) error {
+	h.claimed <- none{}
+	<-sess.Context().Done()
+	return nil
+}
+
+func TestConsumerGroupStatusAndEvents(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	handlers := consumerGroupMockHandlers(t, broker)
+	broker.SetHandlerByMap(handlers)
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Group.Heartbeat.Interval = 10 * time.Millisecond
+	config.Consumer.Group.Return.Events = true
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state := group.Status().State; state != ConsumerGroupStateIdle {
+		t.Errorf("Expected state %s before consuming, got %s", ConsumerGroupStateIdle, state)
+	}
+
+	handler := sessionConsumerGroupHandler{claimed: make(chan none, 1)}
+	consumed := make(chan error, 1)
+	consume := func(ctx context.Context) {
+		consumed <- group.Consume(ctx, []string{"my-topic"}, handler)
+	}
+	go consume(context.Background())
+
+	if event := expectConsumerGroupEvent(t, group, ConsumerGroupJoining); event.Reason != "initial join" {
+		t.Errorf("Expected initial join, got %q", event.Reason)
+	}
+	claims := map[string][]int32{"my-topic": {0}}
+	event := expectConsumerGroupEvent(t, group, ConsumerGroupAssigned)
+	if event.MemberID != "my-member" || event.GenerationID != 3 || !reflect.DeepEqual(event.Claims, claims) {
+		t.Errorf("Unexpected assigned event %+v", event)
+	}
+	<-handler.claimed
+
+	deadline := time.Now().Add(5 * time.Second)
+	for group.Status().LastHeartbeat.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := group.Status()
+	if status.State != ConsumerGroupStateStable || status.MemberID != "my-member" || status.GenerationID != 3 ||
+		!reflect.DeepEqual(status.Claims, claims) || status.LastHeartbeat.IsZero() ||
+		status.LastRebalanceReason != "initial join" {
+		t.Errorf("Unexpected stable status %+v", status)
+	}
+
+	// the coordinator starts a rebalance
+	handlers["HeartbeatRequest"] = NewMockWrapper(&HeartbeatResponse{Err: ErrRebalanceInProgress})
+	broker.SetHandlerByMap(handlers)
+
+	if event := expectConsumerGroupEvent(t, group, ConsumerGroupRevoked); !reflect.DeepEqual(event.Claims, claims) {
+		t.Errorf("Expected revoked claims %v, got %v", claims, event.Claims)
+	}
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+	if status := group.Status(); status.State != ConsumerGroupStateIdle || status.Claims != nil {
+		t.Errorf("Unexpected status between sessions %+v", status)
+	}
+
+	handlers["HeartbeatRequest"] = NewMockHeartbeatResponse(t)
+	broker.SetHandlerByMap(handlers)
+	ctx, cancel := context.WithCancel(context.Background())
+	go consume(ctx)
+
+	if event := expectConsumerGroupEvent(t, group, ConsumerGroupJoining); event.Reason != "rebalance in progress" {
+		t.Errorf("Expected rebalance in progress, got %q", event.Reason)
+	}
+	expectConsumerGroupEvent(t, group, ConsumerGroupAssigned)
+	<-handler.claimed
+	cancel()
+	expectConsumerGroupEvent(t, group, ConsumerGroupRevoked)
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+
+	safeClose(t, group)
+	expectConsumerGroupEvent(t, group, ConsumerGroupLeft)
+	if _, ok := <-group.Events(); ok {
+		t.Error("Expected the events channel to be closed")
+	}
+	if state := group.Status().State; state != ConsumerGroupStateClosed {
+		t.Errorf("Expected state %s after closing, got %s", ConsumerGroupStateClosed, state)
+	}
+}
+
+func TestConsumerGroupRejoin(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(consumerGroupMockHandlers(t, broker))
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Group.Member.UserData = []byte("before")
+	config.Consumer.Group.Return.Events = true
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	handler := sessionConsumerGroupHandler{claimed: make(chan none, 1)}
+	consumed := make(chan error, 1)
+	consume := func() {
+		consumed <- group.Consume(context.Background(), []string{"my-topic"}, handler)
+	}
+	go consume()
+
+	expectConsumerGroupEvent(t, group, ConsumerGroupJoining)
+	expectConsumerGroupEvent(t, group, ConsumerGroupAssigned)
+	<-handler.claimed
+
+	group.SetMemberUserData([]byte("after"))
+	if err := group.Rejoin(); err != nil {
+		t.Fatal(err)
+	}
+	expectConsumerGroupEvent(t, group, ConsumerGroupRevoked)
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+
+	go consume()
+	if event := expectConsumerGroupEvent(t, group, ConsumerGroupJoining); event.Reason != "rejoin requested" {
+		t.Errorf("Expected rejoin requested, got %q", event.Reason)
+	}
+	expectConsumerGroupEvent(t, group, ConsumerGroupAssigned)
+	<-handler.claimed
+
+	// the member left the group before joining it again as a new member, as
+	// the coordinator would otherwise give it its assignment back
+	var userData, requests []string
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *JoinGroupRequest:
+			meta := new(ConsumerGroupMemberMetadata)
+			if err := decode(req.OrderedGroupProtocols[0].Metadata, meta); err != nil {
+				t.Fatal(err)
+			}
+			userData = append(userData, string(meta.UserData))
+			requests = append(requests, fmt.Sprintf("join %q", req.MemberId))
+		case *LeaveGroupRequest:
+			requests = append(requests, fmt.Sprintf("leave %q", req.MemberId))
+		}
+	}
+	if expected := []string{"before", "after"}; !reflect.DeepEqual(userData, expected) {
+		t.Errorf("Expected JoinGroup user data %q, got %q", expected, userData)
+	}
+	if expected := []string{`join ""`, `leave "my-member"`, `join ""`}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected the requests %q, got %q", expected, requests)
+	}
+
+	if err := group.Rejoin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// echoJoinGroupResponse makes the member sending a JoinGroupRequest the only
+// member and leader of generation 3, with the metadata it sent.
+type echoJoinGroupResponse struct{}
+
+func (echoJoinGroupResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*JoinGroupRequest)
+	return &JoinGroupResponse{
+		Version:       req.Version,
+		GenerationId:  3,
+		GroupProtocol: req.OrderedGroupProtocols[0].Name,
+		LeaderId:      "my-member",
+		MemberId:      "my-member",
+		Members:       map[string][]byte{"my-member": req.OrderedGroupProtocols[0].Metadata},
+	}
+}
+
+type statefulPlan struct {
+	previous     map[string]ConsumerGroupMemberAssignment
+	generationID int32
+}
+
+type recordingBalanceStrategy struct {
+	BalanceStrategy
+	plans chan statefulPlan
+}
+
+func (s recordingBalanceStrategy) PlanWithPrevious(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32, previous map[string]ConsumerGroupMemberAssignment, generationID int32) (BalanceStrategyPlan, error) {
+	s.plans <- statefulPlan{previous, generationID}
+	return s.Plan(members, topics)
+}
+
+type assignmentConsumerGroupHandler struct {
+	sessionConsumerGroupHandler
+	assignments chan *ConsumerGroupMemberAssignment
+}
+
+func (h assignmentConsumerGroupHandler) Setup(sess ConsumerGroupSession) error {
+	h.assignments <- sess.Assignment()
+	return nil
+}
+
+func TestConsumerGroupStatefulBalanceStrategy(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	handlers := consumerGroupMockHandlers(t, broker)
+	handlers["JoinGroupRequest"] = echoJoinGroupResponse{}
+	handlers["SyncGroupRequest"] = NewMockSyncGroupResponse(t).
+		SetMemberAssignment(&ConsumerGroupMemberAssignment{
+			Topics:   map[string][]int32{"my-topic": {0}},
+			UserData: []byte("state"),
+		})
+	broker.SetHandlerByMap(handlers)
+
+	strategy := recordingBalanceStrategy{BalanceStrategyRange, make(chan statefulPlan, 1)}
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Group.Rebalance.Strategy = strategy
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	handler := assignmentConsumerGroupHandler{
+		sessionConsumerGroupHandler{claimed: make(chan none, 1)},
+		make(chan *ConsumerGroupMemberAssignment, 1),
+	}
+	consumed := make(chan error, 1)
+	consume := func() {
+		consumed <- group.Consume(context.Background(), []string{"my-topic"}, handler)
+	}
+
+	go consume()
+	if plan := <-strategy.plans; len(plan.previous) != 0 || plan.generationID != 3 {
+		t.Errorf("Unexpected first plan %+v", plan)
+	}
+	assignment := <-handler.assignments
+	if !reflect.DeepEqual(assignment.Topics, map[string][]int32{"my-topic": {0}}) || string(assignment.UserData) != "state" {
+		t.Errorf("Unexpected assignment %+v", assignment)
+	}
+	<-handler.claimed
+
+	if err := group.Rejoin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+
+	go consume()
+	expected := map[string]ConsumerGroupMemberAssignment{
+		"my-member": {Topics: map[string][]int32{"my-topic": {0}}, UserData: []byte("state")},
+	}
+	if plan := <-strategy.plans; !reflect.DeepEqual(plan.previous, expected) {
+		t.Errorf("Expected previous assignments %+v, got %+v", expected, plan.previous)
+	}
+	<-handler.assignments
+	<-handler.claimed
+
+	var generations []int32
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*JoinGroupRequest); ok {
+			meta := new(ConsumerGroupMemberMetadata)
+			if err := decode(req.OrderedGroupProtocols[0].Metadata, meta); err != nil {
+				t.Fatal(err)
+			}
+			if meta.Version != 2 {
+				t.Errorf("Expected member metadata version 2, got %d", meta.Version)
+			}
+			generations = append(generations, meta.GenerationID)
+		}
+	}
+	if expected := []int32{defaultGeneration, 3}; !reflect.DeepEqual(generations, expected) {
+		t.Errorf("Expected reported generations %v, got %v", expected, generations)
+	}
+
+	if err := group.Rejoin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsumerGroupStatusJoinClosedClient(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	handlers := consumerGroupMockHandlers(t, broker)
+	handlers["JoinGroupRequest"] = NewMockJoinGroupResponse(t).SetError(ErrRebalanceInProgress)
+	broker.SetHandlerByMap(handlers)
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Group.Rebalance.Retry.Max = 1
+	config.Consumer.Group.Rebalance.Retry.Backoff = 100 * time.Millisecond
+	config.Consumer.Group.Return.Events = true
+	client, err := NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := NewConsumerGroupFromClient("my-group", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- group.Consume(context.Background(), []string{"my-topic"}, exampleConsumerGroupHandler{})
+	}()
+
+	expectConsumerGroupEvent(t, group, ConsumerGroupJoining)
+	// close the client while backing off after the first JoinGroup
+	joined := func() bool {
+		for _, rr := range broker.History() {
+			if _, ok := rr.Request.(*JoinGroupRequest); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for !joined() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	safeClose(t, client)
+
+	if event := expectConsumerGroupEvent(t, group, ConsumerGroupErrored); event.Err != ErrClosedClient {
+		t.Errorf("Expected %v, got %v", ErrClosedClient, event.Err)
+	}
+	if err := <-consumed; err != ErrClosedConsumerGroup {
+		t.Errorf("Expected %v, got %v", ErrClosedConsumerGroup, err)
+	}
+	if state := group.Status().State; state != ConsumerGroupStateIdle {
+		t.Errorf("Expected state %s after failing to join, got %s", ConsumerGroupStateIdle, state)
+	}
+}
+
+func TestConsumerGroupRejoinWhileJoining(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(consumerGroupMockHandlers(t, broker))
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	config.Consumer.Group.Return.Events = true
+	group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, group)
+
+	broker.SetLatency(100 * time.Millisecond)
+	consumed := make(chan error, 1)
+	go func() {
+		consumed <- group.Consume(context.Background(), []string{"my-topic"}, exampleConsumerGroupHandler{})
+	}()
+
+	expectConsumerGroupEvent(t, group, ConsumerGroupJoining)
+	if err := group.Rejoin(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the session ends as soon as it starts
+	expectConsumerGroupEvent(t, group, ConsumerGroupAssigned)
+	expectConsumerGroupEvent(t, group, ConsumerGroupRevoked)
+	if err := <-consumed; err != nil {
+		t.Fatal(err)
+	}
+	if reason := group.(*consumerGroup).rebalanceReason; reason != "rejoin requested" {
+		t.Errorf("Expected the session to end on rejoin, got %q", reason)
+	}
+}
+
+type resetConsumerGroupHandler struct {
+	sessionConsumerGroupHandler
+	t    time.Time
+	errs chan error
+}
+
+func (h resetConsumerGroupHandler) Setup(sess ConsumerGroupSession) error {
+	h.errs <- sess.ResetOffsetsToTime(h.t, "reset")
+	return nil
+}
+
+func TestConsumerGroupResetOffsetsToTime(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+	for _, committed := range []int64{2, -1} {
+		broker := NewMockBroker(t, 1)
+		handlers := consumerGroupMockHandlers(t, broker)
+		handlers["OffsetFetchRequest"] = NewMockOffsetFetchResponse(t).
+			SetOffset("my-group", "my-topic", 0, committed, "", ErrNoError)
+		handlers["OffsetRequest"] = NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("my-topic", 0, OffsetOldest, 0).
+			SetOffset("my-topic", 0, OffsetNewest, 10).
+			SetOffset("my-topic", 0, ts.UnixNano()/int64(time.Millisecond), 7)
+		broker.SetHandlerByMap(handlers)
+
+		config := NewTestConfig()
+		config.Version = V0_10_2_0
+		group, err := NewConsumerGroup([]string{broker.Addr()}, "my-group", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler := resetConsumerGroupHandler{
+			sessionConsumerGroupHandler: sessionConsumerGroupHandler{claimed: make(chan none, 1)},
+			t:                           ts,
+			errs:                        make(chan error, 1),
+		}
+		consumed := make(chan error, 1)
+		go func() {
+			consumed <- group.Consume(context.Background(), []string{"my-topic"}, handler)
+		}()
+		if err := <-handler.errs; err != nil {
+			t.Fatal(err)
+		}
+		<-handler.claimed
+		if err := group.Rejoin(); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-consumed; err != nil {
+			t.Fatal(err)
+		}
+
+		var block *offsetCommitRequestBlock
+		for _, rr := range broker.History() {
+			if req, ok := rr.Request.(*OffsetCommitRequest); ok {
+				block = req.blocks["my-topic"][0]
+			}
+		}
+		if block == nil {
+			t.Errorf("Expected an offset commit from committed offset %d", committed)
+		} else if block.offset != 7 || block.metadata != "reset" {
+			t.Errorf("Expected offset 7 with metadata reset from committed offset %d, got %d with %q", committed, block.offset, block.metadata)
+		}
+
+		safeClose(t, group)
+		broker.Close()
+	}
+}
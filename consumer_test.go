@@ -103,6 +103,69 @@ func TestConsumerOffsetNewest(t *testing.T) {
 	broker0.Close()
 }
 
+// If partitions are consumed from a time then each of them starts at the offset
+// resolved by the broker for that time, or at the newest offset if there is no
+// message at or after that time.
+func TestConsumerPartitionsAt(t *testing.T) {
+	// Given
+	at := time.Unix(1600000000, 0)
+	ms := at.UnixNano() / int64(time.Millisecond)
+
+	broker0 := NewMockBroker(t, 0)
+	broker0.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker0.Addr(), broker0.BrokerID()).
+			SetLeader("my_topic", 0, broker0.BrokerID()).
+			SetLeader("my_topic", 1, broker0.BrokerID()),
+		"OffsetRequest": NewMockOffsetResponse(t).
+			SetOffset("my_topic", 0, ms, 5).
+			SetOffset("my_topic", 0, OffsetNewest, 10).
+			SetOffset("my_topic", 1, ms, -1).
+			SetOffset("my_topic", 1, OffsetNewest, 20),
+		"FetchRequest": NewMockFetchResponse(t, 1).
+			SetMessage("my_topic", 0, 5, testMsg).
+			SetHighWaterMark("my_topic", 0, 10).
+			SetMessage("my_topic", 1, 20, testMsg).
+			SetHighWaterMark("my_topic", 1, 20),
+	})
+
+	master, err := NewConsumer([]string{broker0.Addr()}, NewTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+	consumers, err := master.ConsumePartitionsAt(map[string][]int32{"my_topic": {0, 1}}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then
+	if hwm := consumers["my_topic"][0].HighWaterMarkOffset(); hwm != 10 {
+		t.Errorf("Expected high water mark 10 before fetching, got %d", hwm)
+	}
+	if hwm := consumers["my_topic"][1].HighWaterMarkOffset(); hwm != 20 {
+		t.Errorf("Expected high water mark 20 before fetching, got %d", hwm)
+	}
+	assertMessageOffset(t, <-consumers["my_topic"][0].Messages(), 5)
+	assertMessageOffset(t, <-consumers["my_topic"][1].Messages(), 20)
+
+	var offsetRequests int
+	for _, rr := range broker0.History() {
+		if _, ok := rr.Request.(*OffsetRequest); ok {
+			offsetRequests++
+		}
+	}
+	if offsetRequests != 2 {
+		t.Errorf("Expected 2 batched offset requests, got %d", offsetRequests)
+	}
+
+	safeClose(t, consumers["my_topic"][0])
+	safeClose(t, consumers["my_topic"][1])
+	safeClose(t, master)
+	broker0.Close()
+}
+
 // It is possible to close a partition consumer and create the same anew.
 func TestConsumerRecreate(t *testing.T) {
 	// Given
@@ -1621,6 +1684,7 @@ func Test_partitionConsumer_parseResponse(t *testing.T) {
 func testConsumerInterceptor(
 	t *testing.T,
 	interceptors []ConsumerInterceptor,
+	expectedMessages int,
 	expectationFn func(*testing.T, int, *ConsumerMessage),
 ) {
 	// Given
@@ -1653,7 +1717,7 @@ func testConsumerInterceptor(
 		t.Fatal(err)
 	}
 
-	for i := 0; i < 10; i++ {
+	for i := 0; i < expectedMessages; i++ {
 		select {
 		case msg := <-consumer.Messages():
 			expectationFn(t, i, msg)
@@ -1723,6 +1787,28 @@ func TestConsumerInterceptors(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) { testConsumerInterceptor(t, tt.interceptors, tt.expectationFn) })
+		t.Run(tt.name, func(t *testing.T) { testConsumerInterceptor(t, tt.interceptors, 10, tt.expectationFn) })
 	}
 }
+
+type evenOffsetFilter struct{}
+
+func (evenOffsetFilter) OnConsume(*ConsumerMessage) {}
+
+func (evenOffsetFilter) Accept(msg *ConsumerMessage) bool {
+	return msg.Offset%2 == 0
+}
+
+func TestConsumerFilterInterceptor(t *testing.T) {
+	interceptor := &appendInterceptor{i: 0}
+	testConsumerInterceptor(t, []ConsumerInterceptor{evenOffsetFilter{}, interceptor}, 5, func(t *testing.T, i int, msg *ConsumerMessage) {
+		if msg.Offset != int64(2*i) {
+			t.Errorf("Expected offset %d, got %d", 2*i, msg.Offset)
+		}
+		// the following interceptors only see the messages which were accepted
+		ev, _ := testMsg.Encode()
+		if expected := string(ev) + strconv.Itoa(i); string(msg.Value) != expected {
+			t.Errorf("Expected value %s, got %s", expected, msg.Value)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package sarama
+
+import (
+	"strconv"
+	"time"
+)
+
+// Keys of the headers a DeadLetterHandler adds to the messages it publishes to
+// the dead letter topic, after the headers of the original message.
+const (
+	// DeadLetterHeaderError is the error returned by the last attempt.
+	DeadLetterHeaderError = "dlq-error"
+	// DeadLetterHeaderTopic, DeadLetterHeaderPartition and
+	// DeadLetterHeaderOffset locate the original message.
+	DeadLetterHeaderTopic     = "dlq-topic"
+	DeadLetterHeaderPartition = "dlq-partition"
+	DeadLetterHeaderOffset    = "dlq-offset"
+	// DeadLetterHeaderAttempts is the number of times the message was handled.
+	DeadLetterHeaderAttempts = "dlq-attempts"
+)
+
+// MessageHandler processes the messages of a consumer group claim one at a
+// time on behalf of a DeadLetterHandler. If it also implements Setup or
+// Cleanup from ConsumerGroupHandler, they are called at the beginning and end
+// of each session.
+type MessageHandler interface {
+	// HandleMessage processes a single message. Returning an error causes
+	// the message to be retried, then published to the dead letter topic.
+	// It must not mark the message, which is done by the DeadLetterHandler.
+	HandleMessage(ConsumerGroupSession, *ConsumerMessage) error
+}
+
+// MessageHandlerFunc is an adapter allowing the use of an ordinary function as
+// a MessageHandler.
+type MessageHandlerFunc func(ConsumerGroupSession, *ConsumerMessage) error
+
+// HandleMessage calls f(sess, msg).
+func (f MessageHandlerFunc) HandleMessage(sess ConsumerGroupSession, msg *ConsumerMessage) error {
+	return f(sess, msg)
+}
+
+// DeadLetterHandler is a ConsumerGroupHandler passing the messages of its
+// claims to a MessageHandler. A message the handler fails to process is
+// retried up to Retry.Max times, then published to a dead letter topic along
+// with headers describing the error and the original message. Messages are
+// marked once they were either processed or published, so poison messages do
+// not block their partition.
+//
+// If a message cannot be published to the dead letter topic, it is not marked
+// and ConsumeClaim returns the error, which ends the session so that the
+// message is consumed again in the next one.
+type DeadLetterHandler struct {
+	// Topic is the dead letter topic.
+	Topic string
+	// Handler processes the messages.
+	Handler MessageHandler
+
+	Retry struct {
+		// The number of times a message is retried before being published
+		// to the dead letter topic (default 3).
+		Max int
+		// How long to wait before retrying a message (default 100ms).
+		// Similar to the Backoff settings of Config.
+		Backoff time.Duration
+		// Called to compute the backoff dynamically instead of Backoff,
+		// with the number of retries so far.
+		BackoffFunc func(retries int, msg *ConsumerMessage) time.Duration
+	}
+
+	producer SyncProducer
+	logger   fieldLogger
+}
+
+// NewDeadLetterHandler returns a DeadLetterHandler passing messages to handler
+// and publishing those it fails to process to topic, using a SyncProducer
+// created from client. You must call Close() on the returned handler once the
+// consumer group is closed, and before closing the client. It fails if the
+// Version of the client config is below V0_11_0_0, as the headers could not
+// be published.
+func NewDeadLetterHandler(client Client, topic string, handler MessageHandler) (*DeadLetterHandler, error) {
+	if !client.Config().Version.IsAtLeast(V0_11_0_0) {
+		return nil, ConfigurationError("DeadLetterHandler requires Version >= V0_11_0_0 to publish headers")
+	}
+
+	producer, err := NewSyncProducerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+	return newDeadLetterHandler(producer, topic, handler, client.Config()), nil
+}
+
+func newDeadLetterHandler(producer SyncProducer, topic string, handler MessageHandler, conf *Config) *DeadLetterHandler {
+	h := &DeadLetterHandler{
+		Topic:    topic,
+		Handler:  handler,
+		producer: producer,
+		logger:   conf.logger(LogField{LogFieldTopic, topic}),
+	}
+	h.Retry.Max = 3
+	h.Retry.Backoff = 100 * time.Millisecond
+	return h
+}
+
+// Setup implements ConsumerGroupHandler.
+func (h *DeadLetterHandler) Setup(sess ConsumerGroupSession) error {
+	if setup, ok := h.Handler.(interface {
+		Setup(ConsumerGroupSession) error
+	}); ok {
+		return setup.Setup(sess)
+	}
+	return nil
+}
+
+// Cleanup implements ConsumerGroupHandler.
+func (h *DeadLetterHandler) Cleanup(sess ConsumerGroupSession) error {
+	if cleanup, ok := h.Handler.(interface {
+		Cleanup(ConsumerGroupSession) error
+	}); ok {
+		return cleanup.Cleanup(sess)
+	}
+	return nil
+}
+
+// ConsumeClaim implements ConsumerGroupHandler.
+func (h *DeadLetterHandler) ConsumeClaim(sess ConsumerGroupSession, claim ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		handled, err := h.handleMessage(sess, msg)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			// the session ended while retrying
+			return nil
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// Close shuts down the producer publishing to the dead letter topic.
+func (h *DeadLetterHandler) Close() error {
+	return h.producer.Close()
+}
+
+// handleMessage processes msg, retrying it then publishing it to the dead
+// letter topic if necessary. It returns false if the session ended before
+// that was done.
+func (h *DeadLetterHandler) handleMessage(sess ConsumerGroupSession, msg *ConsumerMessage) (bool, error) {
+	var err error
+	attempts := 0
+	for {
+		attempts++
+		if err = h.Handler.HandleMessage(sess, msg); err == nil {
+			return true, nil
+		}
+		if attempts > h.Retry.Max {
+			break
+		}
+
+		h.logger.Debugf("dlq/%s failed to handle %s/%d offset %d, retrying: %v\n", h.Topic, msg.Topic, msg.Partition, msg.Offset, err)
+		select {
+		case <-time.After(h.backoff(attempts, msg)):
+		case <-sess.Context().Done():
+			return false, nil
+		}
+	}
+
+	h.logger.Warnf("dlq/%s publishing %s/%d offset %d after %d attempts: %v\n", h.Topic, msg.Topic, msg.Partition, msg.Offset, attempts, err)
+	if _, _, perr := h.producer.SendMessage(h.deadLetter(msg, err, attempts)); perr != nil {
+		return false, perr
+	}
+	return true, nil
+}
+
+func (h *DeadLetterHandler) backoff(retries int, msg *ConsumerMessage) time.Duration {
+	if h.Retry.BackoffFunc != nil {
+		return h.Retry.BackoffFunc(retries, msg)
+	}
+	return h.Retry.Backoff
+}
+
+// deadLetter returns the message to publish to the dead letter topic for msg.
+func (h *DeadLetterHandler) deadLetter(msg *ConsumerMessage, err error, attempts int) *ProducerMessage {
+	headers := make([]RecordHeader, 0, len(msg.Headers)+5)
+	for _, header := range msg.Headers {
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		RecordHeader{Key: []byte(DeadLetterHeaderError), Value: []byte(err.Error())},
+		RecordHeader{Key: []byte(DeadLetterHeaderTopic), Value: []byte(msg.Topic)},
+		RecordHeader{Key: []byte(DeadLetterHeaderPartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		RecordHeader{Key: []byte(DeadLetterHeaderOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		RecordHeader{Key: []byte(DeadLetterHeaderAttempts), Value: []byte(strconv.Itoa(attempts))},
+	)
+
+	dl := &ProducerMessage{Topic: h.Topic, Headers: headers}
+	if msg.Key != nil {
+		dl.Key = ByteEncoder(msg.Key)
+	}
+	if msg.Value != nil {
+		dl.Value = ByteEncoder(msg.Value)
+	}
+	return dl
+}
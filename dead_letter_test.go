@@ -0,0 +1,172 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type deadLetterTestSession struct {
+	ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *deadLetterTestSession) Context() context.Context { return s.ctx }
+
+func (s *deadLetterTestSession) MarkMessage(msg *ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type deadLetterTestClaim struct {
+	ConsumerGroupClaim
+	messages chan *ConsumerMessage
+}
+
+func (c *deadLetterTestClaim) Messages() <-chan *ConsumerMessage { return c.messages }
+
+type deadLetterTestProducer struct {
+	SyncProducer
+	sent []*ProducerMessage
+	err  error
+}
+
+func (p *deadLetterTestProducer) SendMessage(msg *ProducerMessage) (int32, int64, error) {
+	if p.err != nil {
+		return -1, -1, p.err
+	}
+	p.sent = append(p.sent, msg)
+	return 0, int64(len(p.sent)), nil
+}
+
+func newDeadLetterTestClaim(offsets ...int64) *deadLetterTestClaim {
+	claim := &deadLetterTestClaim{messages: make(chan *ConsumerMessage, len(offsets))}
+	for _, offset := range offsets {
+		claim.messages <- &ConsumerMessage{
+			Topic:     "my_topic",
+			Partition: 1,
+			Offset:    offset,
+			Key:       []byte("key"),
+			Value:     []byte("value"),
+			Headers:   []*RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}},
+		}
+	}
+	close(claim.messages)
+	return claim
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	attempts := make(map[int64]int)
+	producer := &deadLetterTestProducer{}
+	h := newDeadLetterHandler(producer, "my_dlq", MessageHandlerFunc(func(_ ConsumerGroupSession, msg *ConsumerMessage) error {
+		attempts[msg.Offset]++
+		if msg.Offset == 1 {
+			return errors.New("poison")
+		}
+		if msg.Offset == 2 && attempts[msg.Offset] == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	}), NewTestConfig())
+	h.Retry.Max = 2
+	h.Retry.Backoff = time.Millisecond
+
+	sess := &deadLetterTestSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(sess, newDeadLetterTestClaim(0, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sess.marked) != 3 {
+		t.Errorf("Expected all messages to be marked, got %v", sess.marked)
+	}
+	if attempts[0] != 1 || attempts[1] != 3 || attempts[2] != 2 {
+		t.Errorf("Unexpected attempts %v", attempts)
+	}
+	if len(producer.sent) != 1 {
+		t.Fatalf("Expected a single dead letter, got %d", len(producer.sent))
+	}
+
+	dl := producer.sent[0]
+	if dl.Topic != "my_dlq" {
+		t.Errorf("Unexpected dead letter topic %s", dl.Topic)
+	}
+	if v, _ := dl.Value.Encode(); string(v) != "value" {
+		t.Errorf("Unexpected dead letter value %s", v)
+	}
+	headers := make(map[string]string)
+	for _, header := range dl.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	expected := map[string]string{
+		"trace":                   "abc",
+		DeadLetterHeaderError:     "poison",
+		DeadLetterHeaderTopic:     "my_topic",
+		DeadLetterHeaderPartition: "1",
+		DeadLetterHeaderOffset:    "1",
+		DeadLetterHeaderAttempts:  "3",
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			t.Errorf("Expected header %s to be %q, got %q", k, v, headers[k])
+		}
+	}
+}
+
+func TestDeadLetterHandlerPublishFailure(t *testing.T) {
+	producer := &deadLetterTestProducer{err: ErrOutOfBrokers}
+	h := newDeadLetterHandler(producer, "my_dlq", MessageHandlerFunc(func(_ ConsumerGroupSession, msg *ConsumerMessage) error {
+		return errors.New("poison")
+	}), NewTestConfig())
+	h.Retry.Max = 0
+
+	sess := &deadLetterTestSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(sess, newDeadLetterTestClaim(0, 1)); err != ErrOutOfBrokers {
+		t.Errorf("Expected ErrOutOfBrokers, got %v", err)
+	}
+	if len(sess.marked) != 0 {
+		t.Errorf("Expected no message to be marked, got %v", sess.marked)
+	}
+}
+
+func TestDeadLetterHandlerSessionEnds(t *testing.T) {
+	producer := &deadLetterTestProducer{}
+	h := newDeadLetterHandler(producer, "my_dlq", MessageHandlerFunc(func(_ ConsumerGroupSession, msg *ConsumerMessage) error {
+		return errors.New("poison")
+	}), NewTestConfig())
+	h.Retry.Backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sess := &deadLetterTestSession{ctx: ctx}
+	if err := h.ConsumeClaim(sess, newDeadLetterTestClaim(0)); err != nil {
+		t.Error(err)
+	}
+	if len(sess.marked) != 0 || len(producer.sent) != 0 {
+		t.Errorf("Expected the message to be left for the next session, marked %v and sent %d", sess.marked, len(producer.sent))
+	}
+}
+
+func TestNewDeadLetterHandlerVersion(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	config := NewTestConfig()
+	config.Version = V0_10_2_0
+	client, err := NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	handler := MessageHandlerFunc(func(ConsumerGroupSession, *ConsumerMessage) error { return nil })
+	if _, err := NewDeadLetterHandler(client, "my_dlq", handler); err == nil {
+		t.Error("Expected a ConfigurationError for a Version below V0_11_0_0")
+	} else if _, ok := err.(ConfigurationError); !ok {
+		t.Errorf("Expected a ConfigurationError, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package sarama
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ClientDNSLookup controls how the host names of the brokers are resolved to
+// connect to them, like `client.dns.lookup` in the JVM version.
+type ClientDNSLookup int8
+
+const (
+	// DNSLookupDefault leaves the resolution of host names to the dialer.
+	DNSLookupDefault ClientDNSLookup = iota
+	// DNSLookupUseAllIPs resolves the host names of the seed and advertised
+	// brokers when connecting, and tries each of their IPs in turn, giving
+	// each of them Net.DialTimeout to accept the connection. Equivalent to
+	// `use_all_dns_ips`. It has no effect with Net.Proxy.Enable, leaving the
+	// resolution to the proxy.
+	DNSLookupUseAllIPs
+	// DNSLookupResolveCanonicalBootstrapServersOnly replaces each seed broker
+	// address with the canonical host names of the IPs it resolves to, which
+	// is required by some Kerberos and TLS setups. Equivalent to
+	// `resolve_canonical_bootstrap_servers_only`.
+	DNSLookupResolveCanonicalBootstrapServersOnly
+)
+
+func (l ClientDNSLookup) String() string {
+	switch l {
+	case DNSLookupDefault:
+		return "default"
+	case DNSLookupUseAllIPs:
+		return "use_all_dns_ips"
+	case DNSLookupResolveCanonicalBootstrapServersOnly:
+		return "resolve_canonical_bootstrap_servers_only"
+	default:
+		return fmt.Sprintf("ClientDNSLookup(%d)", int8(l))
+	}
+}
+
+// hostResolver is the part of net.Resolver used to resolve broker addresses,
+// which tests replace.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+var resolver hostResolver = net.DefaultResolver
+
+// dial connects to the broker at addr, trying each of the IPs its host resolves
+// to in turn with DNSLookupUseAllIPs, unless a proxy resolves it.
+func (c *Config) dial(addr string) (net.Conn, error) {
+	dialer := c.getDialer()
+	if c.Net.DNSLookup != DNSLookupUseAllIPs || c.Net.Proxy.Enable {
+		return dialer.Dial("tcp", addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return dialer.Dial("tcp", addr)
+	}
+	ips, err := resolver.LookupHost(context.Background(), host)
+	if err != nil || len(ips) < 2 {
+		// let the dialer report resolution errors
+		return dialer.Dial("tcp", addr)
+	}
+
+	for _, ip := range ips {
+		var conn net.Conn
+		if conn, err = dialer.Dial("tcp", net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+		c.logger().Debugf("Failed to connect to broker %s at %s, trying its next address: %s\n", addr, ip, err)
+	}
+	return nil, err
+}
+
+// canonicalAddrs returns the addresses of the brokers at the canonical host
+// names of the IPs the hosts of addrs resolve to. Addresses which cannot be
+// resolved are kept as they are.
+func (c *Config) canonicalAddrs(addrs []string) []string {
+	seen := make(map[string]none, len(addrs))
+	canonical := make([]string, 0, len(addrs))
+	add := func(addr string) {
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = none{}
+			canonical = append(canonical, addr)
+		}
+	}
+
+	for _, addr := range addrs {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			add(addr)
+			continue
+		}
+		ips, err := resolver.LookupHost(context.Background(), host)
+		if err != nil {
+			c.logger().Warnf("Failed to resolve seed broker %s: %s\n", addr, err)
+			add(addr)
+			continue
+		}
+		for _, ip := range ips {
+			name := ip
+			if names, err := resolver.LookupAddr(context.Background(), ip); err == nil && len(names) > 0 {
+				name = strings.TrimSuffix(names[0], ".")
+			}
+			add(net.JoinHostPort(name, port))
+		}
+	}
+	return canonical
+}
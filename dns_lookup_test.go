@@ -0,0 +1,120 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type fakeResolver struct {
+	hosts map[string][]string
+	names map[string][]string
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ips, ok := r.hosts[host]; ok {
+		return ips, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if names, ok := r.names[addr]; ok {
+		return names, nil
+	}
+	return nil, errors.New("no such address")
+}
+
+// withResolver replaces the resolver with r until the returned function is
+// called.
+func withResolver(r hostResolver) func() {
+	previous := resolver
+	resolver = r
+	return func() { resolver = previous }
+}
+
+func TestConfigDialUseAllIPs(t *testing.T) {
+	broker := NewMockBroker(t, 1)
+	defer broker.Close()
+
+	ip, port, err := net.SplitHostPort(broker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing listens on the first address, which must be skipped
+	defer withResolver(&fakeResolver{hosts: map[string][]string{
+		"kafka.example": {"127.0.0.254", ip},
+	}})()
+
+	conf := NewTestConfig()
+	conf.Net.DNSLookup = DNSLookupUseAllIPs
+	conn, err := conf.dial(net.JoinHostPort("kafka.example", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if conn.RemoteAddr().String() != broker.Addr() {
+		t.Errorf("Expected to connect to %s, got %s", broker.Addr(), conn.RemoteAddr())
+	}
+}
+
+// addrDialer records the addresses it is asked to dial.
+type addrDialer struct {
+	addrs []string
+}
+
+func (d *addrDialer) Dial(network, addr string) (net.Conn, error) {
+	d.addrs = append(d.addrs, addr)
+	return nil, errors.New("no proxy")
+}
+
+func TestConfigDialUseAllIPsWithProxy(t *testing.T) {
+	defer withResolver(&fakeResolver{hosts: map[string][]string{
+		"kafka.example": {"10.0.0.1", "10.0.0.2"},
+	}})()
+
+	dialer := &addrDialer{}
+	conf := NewTestConfig()
+	conf.Net.DNSLookup = DNSLookupUseAllIPs
+	conf.Net.Proxy.Enable = true
+	conf.Net.Proxy.Dialer = dialer
+	if _, err := conf.dial("kafka.example:9092"); err == nil {
+		t.Fatal("Expected the dial to fail")
+	}
+	if !reflect.DeepEqual(dialer.addrs, []string{"kafka.example:9092"}) {
+		t.Errorf("Expected the proxy to dial the host name, got %v", dialer.addrs)
+	}
+}
+
+func TestClientCanonicalSeedBrokers(t *testing.T) {
+	defer withResolver(&fakeResolver{
+		hosts: map[string][]string{
+			"kafka.example": {"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		names: map[string][]string{
+			"10.0.0.1": {"broker-1.kafka.example."},
+			"10.0.0.2": {"broker-2.kafka.example."},
+		},
+	})()
+
+	conf := NewTestConfig()
+	conf.Net.DNSLookup = DNSLookupResolveCanonicalBootstrapServersOnly
+	client := &client{conf: conf}
+	client.randomizeSeedBrokers([]string{"kafka.example:9092", "unknown.example:9092", "broker-1.kafka.example:9092"})
+
+	addrs := make(map[string]bool)
+	for _, broker := range client.seedBrokers {
+		addrs[broker.Addr()] = true
+	}
+	expected := map[string]bool{
+		"broker-1.kafka.example:9092": true,
+		"broker-2.kafka.example:9092": true,
+		"10.0.0.3:9092":               true,
+		"unknown.example:9092":        true,
+	}
+	if len(client.seedBrokers) != len(expected) || !reflect.DeepEqual(addrs, expected) {
+		t.Errorf("Expected seed brokers %v, got %v", expected, addrs)
+	}
+}
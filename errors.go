@@ -37,6 +37,16 @@ var ErrInsufficientData = errors.New("kafka: insufficient data to decode packet,
 // ErrShuttingDown is returned when a producer receives a message during shutdown.
 var ErrShuttingDown = errors.New("kafka: message received by producer in process of shutting down")
 
+// ErrBufferFull is returned when a producer cannot accept a message because the memory held by the messages
+// in flight reached Producer.Buffer.Memory for longer than Producer.Buffer.MaxBlock.
+var ErrBufferFull = errors.New("kafka: producer buffer memory exhausted")
+
+// ErrServerClosed is returned by Server.Serve and Server.ServeConn after the server has been closed.
+var ErrServerClosed = errors.New("kafka: server closed")
+
+// ErrDeliveryTimeout is returned when a producer fails to deliver a message within Producer.DeliveryTimeout.
+var ErrDeliveryTimeout = errors.New("kafka: message delivery timed out")
+
 // ErrMessageTooLarge is returned when the next message to consume is larger than the configured Consumer.Fetch.Max
 var ErrMessageTooLarge = errors.New("kafka: message is larger than Consumer.Fetch.Max")
 
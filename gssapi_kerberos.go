@@ -203,13 +203,13 @@ func (krbAuth *GSSAPIKerberosAuth) initSecContext(bytes []byte, kerberosClient K
 func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 	kerberosClient, err := krbAuth.NewKerberosClientFunc(krbAuth.Config)
 	if err != nil {
-		Logger.Printf("Kerberos client error: %s", err)
+		broker.logger.Errorf("Kerberos client error: %s", err)
 		return err
 	}
 
 	err = kerberosClient.Login()
 	if err != nil {
-		Logger.Printf("Kerberos client error: %s", err)
+		broker.logger.Errorf("Kerberos client error: %s", err)
 		return err
 	}
 	// Construct SPN using serviceName and host
@@ -221,7 +221,7 @@ func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 	ticket, encKey, err := kerberosClient.GetServiceTicket(spn)
 
 	if err != nil {
-		Logger.Printf("Error getting Kerberos service ticket : %s", err)
+		broker.logger.Errorf("Error getting Kerberos service ticket : %s", err)
 		return err
 	}
 	krbAuth.ticket = ticket
@@ -232,13 +232,13 @@ func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 	for {
 		packBytes, err := krbAuth.initSecContext(receivedBytes, kerberosClient)
 		if err != nil {
-			Logger.Printf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
+			broker.logger.Errorf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
 			return err
 		}
 		requestTime := time.Now()
 		bytesWritten, err := krbAuth.writePackage(broker, packBytes)
 		if err != nil {
-			Logger.Printf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
+			broker.logger.Errorf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
 			return err
 		}
 		broker.updateOutgoingCommunicationMetrics(bytesWritten)
@@ -248,7 +248,7 @@ func (krbAuth *GSSAPIKerberosAuth) Authorize(broker *Broker) error {
 			requestLatency := time.Since(requestTime)
 			broker.updateIncomingCommunicationMetrics(bytesRead, requestLatency)
 			if err != nil {
-				Logger.Printf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
+				broker.logger.Errorf("Error while performing GSSAPI Kerberos Authentication: %s\n", err)
 				return err
 			}
 		} else if krbAuth.step == GSS_API_FINISH {
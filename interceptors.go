@@ -22,6 +22,32 @@ type ConsumerInterceptor interface {
 	OnConsume(*ConsumerMessage)
 }
 
+// ConsumerFilterInterceptor is a ConsumerInterceptor which can also drop the
+// records received by the consumer, so that they never reach the messages
+// channel nor a ConsumerGroupClaim.
+type ConsumerFilterInterceptor interface {
+	ConsumerInterceptor
+
+	// Accept is called after OnConsume. If it returns false, the message is
+	// dropped and is not passed to the following interceptors of the chain.
+	// The consumer still moves past dropped messages, but as they are never
+	// delivered their offsets are not marked by a consumer group, so they
+	// will be consumed again if the group restarts before a later message of
+	// the same partition is marked.
+	Accept(*ConsumerMessage) bool
+}
+
+// ConsumerCommitInterceptor is a ConsumerInterceptor which is also notified of
+// the offsets committed by an OffsetManager using the same Config.
+type ConsumerCommitInterceptor interface {
+	ConsumerInterceptor
+
+	// OnCommit is called after offsets have been successfully committed, with
+	// the committed offsets by topic and partition. The map is shared by all
+	// the interceptors of the chain and must not be modified.
+	OnCommit(offsets map[string]map[int32]int64)
+}
+
 func (msg *ProducerMessage) safelyApplyInterceptor(interceptor ProducerInterceptor) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -32,6 +58,18 @@ func (msg *ProducerMessage) safelyApplyInterceptor(interceptor ProducerIntercept
 	interceptor.OnSend(msg)
 }
 
+// safelyApplyInterceptors passes msg through the chain of interceptors,
+// returning false if one of them filtered it out.
+func (msg *ConsumerMessage) safelyApplyInterceptors(interceptors []ConsumerInterceptor, logger fieldLogger) bool {
+	for _, interceptor := range interceptors {
+		msg.safelyApplyInterceptor(interceptor)
+		if filter, ok := interceptor.(ConsumerFilterInterceptor); ok && !msg.safelyApplyFilter(filter, logger) {
+			return false
+		}
+	}
+	return true
+}
+
 func (msg *ConsumerMessage) safelyApplyInterceptor(interceptor ConsumerInterceptor) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -41,3 +79,26 @@ func (msg *ConsumerMessage) safelyApplyInterceptor(interceptor ConsumerIntercept
 
 	interceptor.OnConsume(msg)
 }
+
+// safelyApplyFilter returns whether filter accepts msg, accepting it if the
+// filter panics.
+func (msg *ConsumerMessage) safelyApplyFilter(filter ConsumerFilterInterceptor, logger fieldLogger) (accepted bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Error when calling consumer filter interceptor: %s, %v\n", filter, r)
+			accepted = true
+		}
+	}()
+
+	return filter.Accept(msg)
+}
+
+func safelyApplyCommitInterceptor(interceptor ConsumerCommitInterceptor, offsets map[string]map[int32]int64, logger fieldLogger) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Error when calling consumer commit interceptor: %s, %v\n", interceptor, r)
+		}
+	}()
+
+	interceptor.OnCommit(offsets)
+}
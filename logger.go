@@ -0,0 +1,165 @@
+package sarama
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogLevel is the severity of a message logged through a LevelledLogger.
+type LogLevel int8
+
+const (
+	// LogLevelDebug is used for verbose messages such as retries and
+	// subscription changes, which are only useful when troubleshooting.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is used for connection and membership lifecycle events.
+	LogLevelInfo
+	// LogLevelWarn is used for recoverable errors.
+	LogLevelWarn
+	// LogLevelError is used for errors that are surfaced to the user or that
+	// Sarama cannot recover from on its own.
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int8(l))
+	}
+}
+
+// Keys of the fields Sarama attaches to the messages it logs.
+const (
+	LogFieldBroker    = "broker"
+	LogFieldTopic     = "topic"
+	LogFieldPartition = "partition"
+	LogFieldGroup     = "group"
+	LogFieldMember    = "member"
+)
+
+// LogField is a key/value pair attached to a logged message.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// LevelledLogger is the interface Sarama uses to log messages when set as
+// Config.Logger. Messages carry a level and the fields identifying the
+// component they originate from (broker id, topic, partition, consumer group
+// and member id), which makes it easy to adapt to structured logging
+// libraries.
+type LevelledLogger interface {
+	// Log logs msg at the given level with the given fields attached, in
+	// addition to the fields of the logger itself.
+	Log(level LogLevel, msg string, fields ...LogField)
+	// With returns a LevelledLogger which attaches the given fields to every
+	// message it logs.
+	With(fields ...LogField) LevelledLogger
+}
+
+// NewStdLevelledLogger returns a LevelledLogger writing the messages at or
+// above minLevel to a StdLogger, with the fields appended in key=value form.
+// If logger is nil, messages are written to the global Logger as it is set at
+// the time they are logged.
+func NewStdLevelledLogger(logger StdLogger, minLevel LogLevel) LevelledLogger {
+	return &stdLevelledLogger{logger: logger, minLevel: minLevel}
+}
+
+type stdLevelledLogger struct {
+	logger   StdLogger
+	minLevel LogLevel
+	fields   []LogField
+}
+
+func (l *stdLevelledLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	if level < l.minLevel {
+		return
+	}
+
+	logger := l.logger
+	if logger == nil {
+		logger = Logger
+	}
+
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for _, fs := range [][]LogField{l.fields, fields} {
+		for _, f := range fs {
+			fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+		}
+	}
+	logger.Print(sb.String())
+}
+
+func (l *stdLevelledLogger) With(fields ...LogField) LevelledLogger {
+	return &stdLevelledLogger{
+		logger:   l.logger,
+		minLevel: l.minLevel,
+		fields:   append(l.fields[:len(l.fields):len(l.fields)], fields...),
+	}
+}
+
+// defaultLevelledLogger preserves the historical behaviour of writing every
+// message to the global Logger.
+var defaultLevelledLogger LevelledLogger = NewStdLevelledLogger(nil, LogLevelDebug)
+
+// fieldLogger wraps the LevelledLogger of a component with the printf style
+// helpers used internally. Its zero value logs to the defaultLevelledLogger.
+type fieldLogger struct {
+	l LevelledLogger
+}
+
+// logger returns the fieldLogger for a component using this config, with the
+// given fields attached. It is safe to call on a nil Config.
+func (c *Config) logger(fields ...LogField) fieldLogger {
+	var l LevelledLogger
+	if c != nil {
+		l = c.Logger
+	}
+	if l == nil {
+		l = defaultLevelledLogger
+	}
+	if len(fields) > 0 {
+		l = l.With(fields...)
+	}
+	return fieldLogger{l: l}
+}
+
+func (f fieldLogger) With(fields ...LogField) fieldLogger {
+	return fieldLogger{l: f.logger().With(fields...)}
+}
+
+func (f fieldLogger) logger() LevelledLogger {
+	if f.l == nil {
+		return defaultLevelledLogger
+	}
+	return f.l
+}
+
+func (f fieldLogger) logf(level LogLevel, format string, v ...interface{}) {
+	f.logger().Log(level, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (f fieldLogger) Debugf(format string, v ...interface{}) {
+	f.logf(LogLevelDebug, format, v...)
+}
+
+func (f fieldLogger) Infof(format string, v ...interface{}) {
+	f.logf(LogLevelInfo, format, v...)
+}
+
+func (f fieldLogger) Warnf(format string, v ...interface{}) {
+	f.logf(LogLevelWarn, format, v...)
+}
+
+func (f fieldLogger) Errorf(format string, v ...interface{}) {
+	f.logf(LogLevelError, format, v...)
+}
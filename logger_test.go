@@ -0,0 +1,114 @@
+package sarama
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type recordingStdLogger struct {
+	lines []string
+}
+
+func (l *recordingStdLogger) Print(v ...interface{}) { l.lines = append(l.lines, fmt.Sprint(v...)) }
+func (l *recordingStdLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+func (l *recordingStdLogger) Println(v ...interface{}) { l.lines = append(l.lines, fmt.Sprint(v...)) }
+
+type recordedMessage struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLevelledLogger struct {
+	lock     *sync.Mutex
+	messages *[]recordedMessage
+	fields   []LogField
+}
+
+func newRecordingLevelledLogger() *recordingLevelledLogger {
+	return &recordingLevelledLogger{lock: &sync.Mutex{}, messages: &[]recordedMessage{}}
+}
+
+func (l *recordingLevelledLogger) Log(level LogLevel, msg string, fields ...LogField) {
+	m := recordedMessage{level: level, msg: msg, fields: make(map[string]interface{})}
+	for _, f := range append(l.fields, fields...) {
+		m.fields[f.Key] = f.Value
+	}
+	l.lock.Lock()
+	*l.messages = append(*l.messages, m)
+	l.lock.Unlock()
+}
+
+func (l *recordingLevelledLogger) With(fields ...LogField) LevelledLogger {
+	return &recordingLevelledLogger{
+		lock:     l.lock,
+		messages: l.messages,
+		fields:   append(l.fields[:len(l.fields):len(l.fields)], fields...),
+	}
+}
+
+func (l *recordingLevelledLogger) recorded() []recordedMessage {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]recordedMessage(nil), *l.messages...)
+}
+
+func TestStdLevelledLogger(t *testing.T) {
+	std := &recordingStdLogger{}
+	logger := NewStdLevelledLogger(std, LogLevelInfo).With(LogField{LogFieldBroker, 3})
+
+	logger.Log(LogLevelDebug, "dropped")
+	logger.Log(LogLevelWarn, "kept", LogField{LogFieldTopic, "my_topic"})
+
+	if len(std.lines) != 1 {
+		t.Fatalf("Expected 1 line to be logged, got %v", std.lines)
+	}
+	if std.lines[0] != "kept broker=3 topic=my_topic" {
+		t.Errorf("Unexpected line logged: %q", std.lines[0])
+	}
+}
+
+func TestDefaultLevelledLoggerUsesGlobalLogger(t *testing.T) {
+	std := &recordingStdLogger{}
+	defer func(l StdLogger) { Logger = l }(Logger)
+	Logger = std
+
+	var conf *Config
+	conf.logger().Debugf("consumer/%s/%d finding new broker\n", "my_topic", 0)
+
+	if len(std.lines) != 1 || std.lines[0] != "consumer/my_topic/0 finding new broker" {
+		t.Errorf("Unexpected lines logged: %q", std.lines)
+	}
+}
+
+func TestConfigLoggerBrokerFields(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	logger := newRecordingLevelledLogger()
+	conf := NewTestConfig()
+	conf.Logger = logger
+
+	broker := NewBroker(seedBroker.Addr())
+	broker.id = 1
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.Connected(); err != nil {
+		t.Fatal(err)
+	}
+	safeClose(t, broker)
+
+	var connected bool
+	for _, m := range logger.recorded() {
+		if m.level == LogLevelInfo && m.fields[LogFieldBroker] == int32(1) {
+			connected = true
+		}
+	}
+	if !connected {
+		t.Errorf("Expected an info message with the broker field, got %+v", logger.recorded())
+	}
+}
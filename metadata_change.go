@@ -0,0 +1,205 @@
+package sarama
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MetadataChangeType is the kind of a MetadataChange.
+type MetadataChangeType int8
+
+const (
+	// BrokerAdded is sent when a broker appears in the cluster metadata, or
+	// when a known broker ID is advertised at a new address.
+	BrokerAdded MetadataChangeType = iota
+	// BrokerRemoved is sent when a broker disappears from the cluster
+	// metadata, or when a known broker ID is advertised at a new address.
+	BrokerRemoved
+	// LeaderChanged is sent when the leader of a partition changes,
+	// including to or from -1 (no leader).
+	LeaderChanged
+	// ISRShrunk is sent when replicas leave the in-sync replicas of a
+	// partition.
+	ISRShrunk
+	// PartitionsAdded is sent when partitions are added to a known topic.
+	PartitionsAdded
+	// TopicDeleted is sent when a known topic is reported as unknown by the
+	// cluster, or is missing from a full metadata refresh.
+	TopicDeleted
+)
+
+func (t MetadataChangeType) String() string {
+	switch t {
+	case BrokerAdded:
+		return "BrokerAdded"
+	case BrokerRemoved:
+		return "BrokerRemoved"
+	case LeaderChanged:
+		return "LeaderChanged"
+	case ISRShrunk:
+		return "ISRShrunk"
+	case PartitionsAdded:
+		return "PartitionsAdded"
+	case TopicDeleted:
+		return "TopicDeleted"
+	default:
+		return fmt.Sprintf("MetadataChangeType(%d)", int8(t))
+	}
+}
+
+// MetadataChange describes a difference between two successive metadata
+// responses received by a Client. Only the fields relevant to its Type are set.
+type MetadataChange struct {
+	Type MetadataChangeType
+
+	// BrokerID and Addr identify the broker of BrokerAdded and BrokerRemoved
+	// changes.
+	BrokerID int32
+	Addr     string
+
+	// Topic is set for all but broker changes, and Partition for
+	// LeaderChanged and ISRShrunk changes.
+	Topic     string
+	Partition int32
+
+	// OldLeader and NewLeader are the broker IDs of the leaders of the
+	// partition of a LeaderChanged change, or -1 if it had none.
+	OldLeader, NewLeader int32
+
+	// Replicas are the IDs of the replicas which left the in-sync replicas
+	// in an ISRShrunk change.
+	Replicas []int32
+
+	// Partitions are the sorted IDs of the partitions added to the topic of
+	// a PartitionsAdded change.
+	Partitions []int32
+}
+
+// metadataSnapshot is the cluster state of a client before an update, for
+// computing the changes made by it.
+type metadataSnapshot struct {
+	brokers map[int32]string
+	topics  map[string]map[int32]*PartitionMetadata
+}
+
+// snapshotMetadata returns the current cluster state. The partition maps are
+// shared since updateMetadata replaces rather than modifies them. You must
+// hold the read lock before calling this function.
+func (client *client) snapshotMetadata() *metadataSnapshot {
+	s := &metadataSnapshot{
+		brokers: make(map[int32]string, len(client.brokers)),
+		topics:  make(map[string]map[int32]*PartitionMetadata, len(client.metadata)),
+	}
+	for id, broker := range client.brokers {
+		s.brokers[id] = broker.Addr()
+	}
+	for topic, partitions := range client.metadata {
+		s.topics[topic] = partitions
+	}
+	return s
+}
+
+// metadataChanges returns the changes from the previous to the current state,
+// which was updated from data.
+func metadataChanges(previous, current *metadataSnapshot, data *MetadataResponse, allKnownMetaData bool) []MetadataChange {
+	var changes []MetadataChange
+
+	for _, id := range sortedBrokerIDs(current.brokers) {
+		if addr, ok := previous.brokers[id]; !ok || addr != current.brokers[id] {
+			changes = append(changes, MetadataChange{Type: BrokerAdded, BrokerID: id, Addr: current.brokers[id]})
+		}
+	}
+	for _, id := range sortedBrokerIDs(previous.brokers) {
+		if addr, ok := current.brokers[id]; !ok || addr != previous.brokers[id] {
+			changes = append(changes, MetadataChange{Type: BrokerRemoved, BrokerID: id, Addr: previous.brokers[id]})
+		}
+	}
+
+	responded := make(map[string]KError, len(data.Topics))
+	for _, topic := range data.Topics {
+		responded[topic.Name] = topic.Err
+
+		before, after := previous.topics[topic.Name], current.topics[topic.Name]
+		if before == nil || after == nil {
+			continue
+		}
+		changes = append(changes, partitionChanges(topic.Name, before, after)...)
+	}
+
+	var deleted []string
+	for topic := range previous.topics {
+		kerr, ok := responded[topic]
+		if (ok && kerr == ErrUnknownTopicOrPartition) || (!ok && allKnownMetaData) {
+			deleted = append(deleted, topic)
+		}
+	}
+	sort.Strings(deleted)
+	for _, topic := range deleted {
+		changes = append(changes, MetadataChange{Type: TopicDeleted, Topic: topic})
+	}
+
+	return changes
+}
+
+func partitionChanges(topic string, before, after map[int32]*PartitionMetadata) []MetadataChange {
+	var changes []MetadataChange
+
+	ids := make([]int32, 0, len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var added []int32
+	for _, id := range ids {
+		old, ok := before[id]
+		if !ok {
+			added = append(added, id)
+			continue
+		}
+		partition := after[id]
+
+		if old.Leader != partition.Leader {
+			changes = append(changes, MetadataChange{
+				Type:      LeaderChanged,
+				Topic:     topic,
+				Partition: id,
+				OldLeader: old.Leader,
+				NewLeader: partition.Leader,
+			})
+		}
+
+		var left []int32
+		for _, replica := range old.Isr {
+			if !int32Contains(partition.Isr, replica) {
+				left = append(left, replica)
+			}
+		}
+		if len(left) > 0 {
+			changes = append(changes, MetadataChange{Type: ISRShrunk, Topic: topic, Partition: id, Replicas: left})
+		}
+	}
+
+	if len(added) > 0 {
+		changes = append([]MetadataChange{{Type: PartitionsAdded, Topic: topic, Partitions: added}}, changes...)
+	}
+	return changes
+}
+
+func sortedBrokerIDs(brokers map[int32]string) []int32 {
+	ids := make([]int32, 0, len(brokers))
+	for id := range brokers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func int32Contains(s []int32, v int32) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
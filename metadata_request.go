@@ -4,13 +4,21 @@ type MetadataRequest struct {
 	Version                int16
 	Topics                 []string
 	AllowAutoTopicCreation bool
+	// NoTopics requests the metadata of no topic instead of all of them when
+	// Topics is empty, e.g. to only describe the brokers (version 1+).
+	NoTopics bool
+	// IncludeClusterAuthorizedOperations and IncludeTopicAuthorizedOperations
+	// request the operations the client is authorized to perform on the
+	// cluster and on the topics (version 8+).
+	IncludeClusterAuthorizedOperations bool
+	IncludeTopicAuthorizedOperations   bool
 }
 
 func (r *MetadataRequest) encode(pe packetEncoder) error {
-	if r.Version < 0 || r.Version > 5 {
+	if r.Version < 0 || r.Version > 8 {
 		return PacketEncodingError{"invalid or unsupported MetadataRequest version field"}
 	}
-	if r.Version == 0 || len(r.Topics) > 0 {
+	if r.Version == 0 || len(r.Topics) > 0 || r.NoTopics {
 		err := pe.putArrayLength(len(r.Topics))
 		if err != nil {
 			return err
@@ -28,6 +36,10 @@ func (r *MetadataRequest) encode(pe packetEncoder) error {
 	if r.Version > 3 {
 		pe.putBool(r.AllowAutoTopicCreation)
 	}
+	if r.Version > 7 {
+		pe.putBool(r.IncludeClusterAuthorizedOperations)
+		pe.putBool(r.IncludeTopicAuthorizedOperations)
+	}
 	return nil
 }
 
@@ -37,6 +49,9 @@ func (r *MetadataRequest) decode(pd packetDecoder, version int16) error {
 	if err != nil {
 		return err
 	}
+	if size == 0 && r.Version > 0 {
+		r.NoTopics = true
+	}
 	if size > 0 {
 		r.Topics = make([]string, size)
 		for i := range r.Topics {
@@ -54,6 +69,14 @@ func (r *MetadataRequest) decode(pd packetDecoder, version int16) error {
 		}
 		r.AllowAutoTopicCreation = autoCreation
 	}
+	if r.Version > 7 {
+		if r.IncludeClusterAuthorizedOperations, err = pd.getBool(); err != nil {
+			return err
+		}
+		if r.IncludeTopicAuthorizedOperations, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -79,6 +102,12 @@ func (r *MetadataRequest) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 5:
 		return V1_0_0_0
+	case 6:
+		return V2_0_0_0
+	case 7:
+		return V2_1_0_0
+	case 8:
+		return V2_3_0_0
 	default:
 		return MinVersion
 	}
@@ -30,6 +30,10 @@ var (
 		0xff, 0xff, 0xff, 0xff,
 	}
 
+	metadataRequestEmptyTopicsV1 = []byte{
+		0x00, 0x00, 0x00, 0x00,
+	}
+
 	metadataRequestOneTopicV1    = metadataRequestOneTopicV0
 	metadataRequestThreeTopicsV1 = metadataRequestThreeTopicsV0
 
@@ -60,6 +64,12 @@ var (
 	metadataRequestNoTopicsV5     = append(metadataRequestNoTopicsV1, byte(0))
 	metadataRequestAutoCreateV5   = append(metadataRequestOneTopicV3, byte(1))
 	metadataRequestNoAutoCreateV5 = append(metadataRequestOneTopicV3, byte(0))
+
+	// The v8 metadata request has additional fields for including the
+	// authorized operations of the cluster and of the topics in the response.
+	// The v6 and v7 requests are the same as v5.
+
+	metadataRequestAuthorizedOperationsV8 = append(metadataRequestOneTopicV3, byte(0), byte(1), byte(0))
 )
 
 func TestMetadataRequestV0(t *testing.T) {
@@ -78,6 +88,10 @@ func TestMetadataRequestV1(t *testing.T) {
 	request.Version = 1
 	testRequest(t, "no topics", request, metadataRequestNoTopicsV1)
 
+	request.NoTopics = true
+	testRequest(t, "no topics requested", request, metadataRequestEmptyTopicsV1)
+
+	request.NoTopics = false
 	request.Topics = []string{"topic1"}
 	testRequest(t, "one topic", request, metadataRequestOneTopicV1)
 
@@ -136,3 +150,11 @@ func TestMetadataRequestV5(t *testing.T) {
 	request.AllowAutoTopicCreation = false
 	testRequest(t, "one topic", request, metadataRequestNoAutoCreateV5)
 }
+
+func TestMetadataRequestV8(t *testing.T) {
+	request := new(MetadataRequest)
+	request.Version = 8
+	request.Topics = []string{"topic1"}
+	request.IncludeClusterAuthorizedOperations = true
+	testRequest(t, "one topic", request, metadataRequestAuthorizedOperationsV8)
+}
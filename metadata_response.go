@@ -4,6 +4,7 @@ type PartitionMetadata struct {
 	Err             KError
 	ID              int32
 	Leader          int32
+	LeaderEpoch     int32 // Only valid for Version >= 7
 	Replicas        []int32
 	Isr             []int32
 	OfflineReplicas []int32
@@ -26,6 +27,13 @@ func (pm *PartitionMetadata) decode(pd packetDecoder, version int16) (err error)
 		return err
 	}
 
+	if version >= 7 {
+		pm.LeaderEpoch, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	}
+
 	pm.Replicas, err = pd.getInt32Array()
 	if err != nil {
 		return err
@@ -51,6 +59,10 @@ func (pm *PartitionMetadata) encode(pe packetEncoder, version int16) (err error)
 	pe.putInt32(pm.ID)
 	pe.putInt32(pm.Leader)
 
+	if version >= 7 {
+		pe.putInt32(pm.LeaderEpoch)
+	}
+
 	err = pe.putInt32Array(pm.Replicas)
 	if err != nil {
 		return err
@@ -76,6 +88,15 @@ type TopicMetadata struct {
 	Name       string
 	IsInternal bool // Only valid for Version >= 1
 	Partitions []*PartitionMetadata
+	// TopicAuthorizedOperations is a bitfield of the AclOperations allowed
+	// on the topic, if requested (Version >= 8). See AuthorizedOperations.
+	TopicAuthorizedOperations int32
+}
+
+// AuthorizedOperations returns the operations the client is allowed to perform
+// on the topic, or nil if they were not requested.
+func (tm *TopicMetadata) AuthorizedOperations() []AclOperation {
+	return authorizedOperations(tm.TopicAuthorizedOperations)
 }
 
 func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
@@ -110,6 +131,15 @@ func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 {
+		tm.TopicAuthorizedOperations, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	} else {
+		tm.TopicAuthorizedOperations = authorizedOperationsOmitted
+	}
+
 	return nil
 }
 
@@ -137,6 +167,10 @@ func (tm *TopicMetadata) encode(pe packetEncoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 {
+		pe.putInt32(tm.TopicAuthorizedOperations)
+	}
+
 	return nil
 }
 
@@ -147,6 +181,15 @@ type MetadataResponse struct {
 	ClusterID      *string
 	ControllerID   int32
 	Topics         []*TopicMetadata
+	// ClusterAuthorizedOperations is a bitfield of the AclOperations allowed
+	// on the cluster, if requested (Version >= 8). See AuthorizedOperations.
+	ClusterAuthorizedOperations int32
+}
+
+// AuthorizedOperations returns the operations the client is allowed to perform
+// on the cluster, or nil if they were not requested.
+func (r *MetadataResponse) AuthorizedOperations() []AclOperation {
+	return authorizedOperations(r.ClusterAuthorizedOperations)
 }
 
 func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
@@ -203,6 +246,15 @@ func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
 		}
 	}
 
+	if version >= 8 {
+		r.ClusterAuthorizedOperations, err = pd.getInt32()
+		if err != nil {
+			return err
+		}
+	} else {
+		r.ClusterAuthorizedOperations = authorizedOperationsOmitted
+	}
+
 	return nil
 }
 
@@ -244,6 +296,10 @@ func (r *MetadataResponse) encode(pe packetEncoder) error {
 		}
 	}
 
+	if r.Version >= 8 {
+		pe.putInt32(r.ClusterAuthorizedOperations)
+	}
+
 	return nil
 }
 
@@ -269,6 +325,12 @@ func (r *MetadataResponse) requiredVersion() KafkaVersion {
 		return V0_11_0_0
 	case 5:
 		return V1_0_0_0
+	case 6:
+		return V2_0_0_0
+	case 7:
+		return V2_1_0_0
+	case 8:
+		return V2_3_0_0
 	default:
 		return MinVersion
 	}
@@ -1,6 +1,9 @@
 package sarama
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 var (
 	emptyMetadataResponseV0 = []byte{
@@ -100,6 +103,27 @@ var (
 		0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02,
 		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
 	}
+
+	noBrokersOneTopicWithAuthorizedOperationsV8 = []byte{
+		0x00, 0x00, 0x00, 0x05,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x09, 'c', 'l', 'u', 's', 't', 'e', 'r', 'I', 'd',
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00,
+		0x00, 0x03, 'f', 'o', 'o',
+		0x00,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x07,
+		0x00, 0x00, 0x00, 0x0a,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x18, // read, write, describe
+		0x80, 0x00, 0x00, 0x00, // omitted
+	}
 )
 
 func TestEmptyMetadataResponseV0(t *testing.T) {
@@ -288,3 +312,22 @@ func TestMetadataResponseWithOfflineReplicasV5(t *testing.T) {
 		t.Error("Decoding produced", len(response.Topics[0].Partitions[0].OfflineReplicas), "should have been 1!")
 	}
 }
+
+func TestMetadataResponseWithAuthorizedOperationsV8(t *testing.T) {
+	response := MetadataResponse{}
+
+	testVersionDecodable(t, "no brokers, 1 topic with authorized operations V8", &response, noBrokersOneTopicWithAuthorizedOperationsV8, 8)
+	if len(response.Topics) != 1 || len(response.Topics[0].Partitions) != 1 {
+		t.Fatal("Decoding produced", response.Topics, "should have had 1 topic with 1 partition!")
+	}
+	if epoch := response.Topics[0].Partitions[0].LeaderEpoch; epoch != 10 {
+		t.Error("Decoding produced", epoch, "should have been 10!")
+	}
+	ops := response.Topics[0].AuthorizedOperations()
+	if !reflect.DeepEqual(ops, []AclOperation{AclOperationRead, AclOperationWrite, AclOperationDescribe}) {
+		t.Error("Decoding produced", ops, "should have been [Read Write Describe]!")
+	}
+	if ops := response.AuthorizedOperations(); ops != nil {
+		t.Error("Decoding produced", ops, "should have been nil!")
+	}
+}
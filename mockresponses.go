@@ -132,17 +132,20 @@ func (m *MockDescribeGroupsResponse) For(reqBody versionedDecoder) encoderWithHe
 
 // MockMetadataResponse is a `MetadataResponse` builder.
 type MockMetadataResponse struct {
-	controllerID int32
-	leaders      map[string]map[int32]int32
-	brokers      map[string]int32
-	t            TestReporter
+	controllerID      int32
+	leaders           map[string]map[int32]int32
+	brokers           map[string]int32
+	clusterOperations int32
+	topicOperations   map[string]int32
+	t                 TestReporter
 }
 
 func NewMockMetadataResponse(t TestReporter) *MockMetadataResponse {
 	return &MockMetadataResponse{
-		leaders: make(map[string]map[int32]int32),
-		brokers: make(map[string]int32),
-		t:       t,
+		leaders:         make(map[string]map[int32]int32),
+		brokers:         make(map[string]int32),
+		topicOperations: make(map[string]int32),
+		t:               t,
 	}
 }
 
@@ -166,6 +169,20 @@ func (mmr *MockMetadataResponse) SetController(brokerID int32) *MockMetadataResp
 	return mmr
 }
 
+// SetClusterAuthorizedOperations sets the bitfield of the operations authorized
+// on the cluster, returned if requested.
+func (mmr *MockMetadataResponse) SetClusterAuthorizedOperations(operations int32) *MockMetadataResponse {
+	mmr.clusterOperations = operations
+	return mmr
+}
+
+// SetTopicAuthorizedOperations sets the bitfield of the operations authorized
+// on topic, returned if requested.
+func (mmr *MockMetadataResponse) SetTopicAuthorizedOperations(topic string, operations int32) *MockMetadataResponse {
+	mmr.topicOperations[topic] = operations
+	return mmr
+}
+
 func (mmr *MockMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader {
 	metadataRequest := reqBody.(*MetadataRequest)
 	metadataResponse := &MetadataResponse{
@@ -183,17 +200,28 @@ func (mmr *MockMetadataResponse) For(reqBody versionedDecoder) encoderWithHeader
 		replicas = append(replicas, brokerID)
 	}
 
-	if len(metadataRequest.Topics) == 0 {
+	if len(metadataRequest.Topics) == 0 && !metadataRequest.NoTopics {
 		for topic, partitions := range mmr.leaders {
 			for partition, brokerID := range partitions {
 				metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
 			}
 		}
-		return metadataResponse
+	} else {
+		for _, topic := range metadataRequest.Topics {
+			for partition, brokerID := range mmr.leaders[topic] {
+				metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
+			}
+		}
 	}
-	for _, topic := range metadataRequest.Topics {
-		for partition, brokerID := range mmr.leaders[topic] {
-			metadataResponse.AddTopicPartition(topic, partition, brokerID, replicas, replicas, offlineReplicas, ErrNoError)
+
+	metadataResponse.ClusterAuthorizedOperations = authorizedOperationsOmitted
+	if metadataRequest.IncludeClusterAuthorizedOperations {
+		metadataResponse.ClusterAuthorizedOperations = mmr.clusterOperations
+	}
+	for _, topic := range metadataResponse.Topics {
+		topic.TopicAuthorizedOperations = authorizedOperationsOmitted
+		if metadataRequest.IncludeTopicAuthorizedOperations {
+			topic.TopicAuthorizedOperations = mmr.topicOperations[topic.Name]
 		}
 	}
 	return metadataResponse
@@ -981,9 +1009,10 @@ func (mr *MockListAclsResponse) For(reqBody versionedDecoder) encoderWithHeader
 }
 
 type MockSaslAuthenticateResponse struct {
-	t             TestReporter
-	kerror        KError
-	saslAuthBytes []byte
+	t                 TestReporter
+	kerror            KError
+	saslAuthBytes     []byte
+	sessionLifetimeMs int64
 }
 
 func NewMockSaslAuthenticateResponse(t TestReporter) *MockSaslAuthenticateResponse {
@@ -991,9 +1020,12 @@ func NewMockSaslAuthenticateResponse(t TestReporter) *MockSaslAuthenticateRespon
 }
 
 func (msar *MockSaslAuthenticateResponse) For(reqBody versionedDecoder) encoderWithHeader {
+	req := reqBody.(*SaslAuthenticateRequest)
 	res := &SaslAuthenticateResponse{}
+	res.Version = req.Version
 	res.Err = msar.kerror
 	res.SaslAuthBytes = msar.saslAuthBytes
+	res.SessionLifetimeMs = msar.sessionLifetimeMs
 	return res
 }
 
@@ -1007,6 +1039,11 @@ func (msar *MockSaslAuthenticateResponse) SetAuthBytes(saslAuthBytes []byte) *Mo
 	return msar
 }
 
+func (msar *MockSaslAuthenticateResponse) SetSessionLifetimeMs(sessionLifetimeMs int64) *MockSaslAuthenticateResponse {
+	msar.sessionLifetimeMs = sessionLifetimeMs
+	return msar
+}
+
 type MockDeleteAclsResponse struct {
 	t TestReporter
 }
@@ -3,6 +3,7 @@ package mocks
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Shopify/sarama"
 )
@@ -63,6 +64,25 @@ func (c *Consumer) ConsumePartition(topic string, partition int32, offset int64)
 	return pc, nil
 }
 
+// ConsumePartitionsAt implements the ConsumePartitionsAt method from the sarama.Consumer interface.
+// Offsets are resolved by the broker, so only expectations registered with AnyOffset
+// can be satisfied. Before you can start consuming a partition, you have to set
+// expectations on it using ExpectConsumePartition.
+func (c *Consumer) ConsumePartitionsAt(partitions map[string][]int32, t time.Time) (map[string]map[int32]sarama.PartitionConsumer, error) {
+	result := make(map[string]map[int32]sarama.PartitionConsumer, len(partitions))
+	for topic, topicPartitions := range partitions {
+		result[topic] = make(map[int32]sarama.PartitionConsumer, len(topicPartitions))
+		for _, partition := range topicPartitions {
+			pc, err := c.ConsumePartition(topic, partition, AnyOffset)
+			if err != nil {
+				return nil, err
+			}
+			result[topic][partition] = pc
+		}
+	}
+	return result, nil
+}
+
 // Topics returns a list of topics, as registered with SetTopicMetadata
 func (c *Consumer) Topics() ([]string, error) {
 	c.l.Lock()
@@ -106,6 +106,53 @@ func (sp *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
 	return errOutOfExpectations
 }
 
+// SendMessagesWithResults corresponds with the SendMessagesWithResults method of sarama's
+// SyncProducer implementation. You have to set expectations on the mock producer before calling
+// SendMessagesWithResults, so it knows how to handle them. If there is no more remaining
+// expectations when SendMessagesWithResults is called, the mock producer will write an error
+// to the test state object.
+func (sp *SyncProducer) SendMessagesWithResults(msgs []*sarama.ProducerMessage) []sarama.ProducerResult {
+	sp.l.Lock()
+	defer sp.l.Unlock()
+
+	results := make([]sarama.ProducerResult, len(msgs))
+	if len(sp.expectations) < len(msgs) {
+		sp.t.Errorf("Insufficient expectations set on this mock producer to handle the input messages.")
+		for i := range results {
+			results[i] = sarama.ProducerResult{Partition: -1, Offset: -1, Err: errOutOfExpectations}
+		}
+		return results
+	}
+
+	expectations := sp.expectations[0:len(msgs)]
+	sp.expectations = sp.expectations[len(msgs):]
+
+	for i, expectation := range expectations {
+		results[i] = sarama.ProducerResult{Partition: -1, Offset: -1}
+		if expectation.CheckFunction != nil {
+			val, err := msgs[i].Value.Encode()
+			if err != nil {
+				sp.t.Errorf("Input message encoding failed: %s", err.Error())
+				results[i].Err = err
+				continue
+			}
+			if errCheck := expectation.CheckFunction(val); errCheck != nil {
+				sp.t.Errorf("Check function returned an error: %s", errCheck.Error())
+				results[i].Err = errCheck
+				continue
+			}
+		}
+		if expectation.Result != errProduceSuccess {
+			results[i].Err = expectation.Result
+			continue
+		}
+		sp.lastOffset++
+		msgs[i].Offset = sp.lastOffset
+		results[i] = sarama.ProducerResult{Partition: 0, Offset: msgs[i].Offset, Timestamp: msgs[i].Timestamp}
+	}
+	return results
+}
+
 // Close corresponds with the Close method of sarama's SyncProducer implementation.
 // By closing a mock syncproducer, you also tell it that no more SendMessage calls will follow,
 // so it will write an error to the test state if there's any remaining expectations.
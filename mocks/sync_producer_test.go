@@ -177,6 +177,35 @@ func TestSyncProducerWithCheckerFunctionForSendMessagesWithoutError(t *testing.T
 	}
 }
 
+func TestSyncProducerSendMessagesWithResults(t *testing.T) {
+	trm := newTestReporterMock()
+
+	sp := NewSyncProducer(trm, nil)
+	sp.ExpectSendMessageAndSucceed()
+	sp.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+	msgs := []*sarama.ProducerMessage{
+		{Topic: "test", Value: sarama.StringEncoder("test")},
+		{Topic: "test", Value: sarama.StringEncoder("test")},
+	}
+	results := sp.SendMessagesWithResults(msgs)
+
+	if results[0].Err != nil || results[0].Offset != 1 {
+		t.Error("Expected the first message to succeed, found: ", results[0])
+	}
+	if results[1].Err != sarama.ErrOutOfBrokers || results[1].Offset != -1 {
+		t.Error("Expected the second message to fail, found: ", results[1])
+	}
+
+	if err := sp.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if len(trm.errors) != 0 {
+		t.Error("Expected no errors to be reported, found: ", trm.errors)
+	}
+}
+
 func TestSyncProducerSendMessagesExpectationsMismatchTooFew(t *testing.T) {
 	trm := newTestReporterMock()
 
@@ -25,6 +25,24 @@ type OffsetManager interface {
 	Commit()
 }
 
+// OffsetStore is an external store for consumed partition offsets. When
+// configured through Consumer.Offsets.Store, the OffsetManager loads initial
+// offsets from and commits marked offsets to the store instead of sending
+// OffsetFetch/OffsetCommit requests to the group coordinator. Group membership
+// is still managed by the broker. Implementations must be safe for concurrent
+// use.
+type OffsetStore interface {
+	// Load returns the next offset to consume for the given group and
+	// topic/partition, alongside its metadata string. It should return a
+	// negative offset if no offset was stored for this partition yet, in which
+	// case `Consumer.Offsets.Initial` is used.
+	Load(group, topic string, partition int32) (int64, string, error)
+
+	// Save persists the offset and metadata string for the given group and
+	// topic/partition.
+	Save(group, topic string, partition int32, offset int64, metadata string) error
+}
+
 type offsetManager struct {
 	client Client
 	conf   *Config
@@ -139,6 +157,10 @@ func (om *offsetManager) computeBackoff(retries int) time.Duration {
 }
 
 func (om *offsetManager) fetchInitialOffset(topic string, partition int32, retries int) (int64, string, error) {
+	if store := om.conf.Consumer.Offsets.Store; store != nil {
+		return store.Load(om.group, topic, partition)
+	}
+
 	broker, err := om.coordinator()
 	if err != nil {
 		if retries <= 0 {
@@ -248,6 +270,11 @@ func (om *offsetManager) Commit() {
 }
 
 func (om *offsetManager) flushToBroker() {
+	if om.conf.Consumer.Offsets.Store != nil {
+		om.notifyCommit(om.flushToStore())
+		return
+	}
+
 	req := om.constructRequest()
 	if req == nil {
 		return
@@ -267,7 +294,38 @@ func (om *offsetManager) flushToBroker() {
 		return
 	}
 
-	om.handleResponse(broker, req, resp)
+	om.notifyCommit(om.handleResponse(broker, req, resp))
+}
+
+// flushToStore saves the dirty offsets to Consumer.Offsets.Store, returning
+// the ones which were saved successfully.
+func (om *offsetManager) flushToStore() map[string]map[int32]int64 {
+	store := om.conf.Consumer.Offsets.Store
+	committed := make(map[string]map[int32]int64)
+
+	om.pomsLock.RLock()
+	defer om.pomsLock.RUnlock()
+
+	for _, topicManagers := range om.poms {
+		for _, pom := range topicManagers {
+			pom.lock.Lock()
+			dirty, offset, metadata := pom.dirty, pom.offset, pom.metadata
+			pom.lock.Unlock()
+
+			if !dirty {
+				continue
+			}
+
+			if err := store.Save(om.group, pom.topic, pom.partition, offset, metadata); err != nil {
+				pom.handleError(err)
+				continue
+			}
+			pom.updateCommitted(offset, metadata)
+			addCommittedOffset(committed, pom.topic, pom.partition, offset)
+		}
+	}
+
+	return committed
 }
 
 func (om *offsetManager) constructRequest() *OffsetCommitRequest {
@@ -311,10 +369,14 @@ func (om *offsetManager) constructRequest() *OffsetCommitRequest {
 	return nil
 }
 
-func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest, resp *OffsetCommitResponse) {
+// handleResponse processes the result of a commit, returning the offsets which
+// were committed successfully.
+func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest, resp *OffsetCommitResponse) map[string]map[int32]int64 {
 	om.pomsLock.RLock()
 	defer om.pomsLock.RUnlock()
 
+	committed := make(map[string]map[int32]int64)
+
 	for _, topicManagers := range om.poms {
 		for _, pom := range topicManagers {
 			if req.blocks[pom.topic] == nil || req.blocks[pom.topic][pom.partition] == nil {
@@ -337,6 +399,7 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 			case ErrNoError:
 				block := req.blocks[pom.topic][pom.partition]
 				pom.updateCommitted(block.offset, block.metadata)
+				addCommittedOffset(committed, pom.topic, pom.partition, block.offset)
 			case ErrNotLeaderForPartition, ErrLeaderNotAvailable,
 				ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
 				// not a critical error, we just need to redispatch
@@ -359,6 +422,29 @@ func (om *offsetManager) handleResponse(broker *Broker, req *OffsetCommitRequest
 			}
 		}
 	}
+
+	return committed
+}
+
+// notifyCommit passes the offsets which were just committed to the consumer
+// interceptors implementing ConsumerCommitInterceptor.
+func (om *offsetManager) notifyCommit(committed map[string]map[int32]int64) {
+	if len(committed) == 0 {
+		return
+	}
+	logger := om.conf.logger(LogField{LogFieldGroup, om.group})
+	for _, interceptor := range om.conf.Consumer.Interceptors {
+		if commitInterceptor, ok := interceptor.(ConsumerCommitInterceptor); ok {
+			safelyApplyCommitInterceptor(commitInterceptor, committed, logger)
+		}
+	}
+}
+
+func addCommittedOffset(committed map[string]map[int32]int64, topic string, partition int32, offset int64) {
+	if committed[topic] == nil {
+		committed[topic] = make(map[int32]int64)
+	}
+	committed[topic][partition] = offset
 }
 
 func (om *offsetManager) handleError(err error) {
@@ -532,6 +618,17 @@ func (pom *partitionOffsetManager) ResetOffset(offset int64, metadata string) {
 	}
 }
 
+// setOffset sets the offset to commit, whether it is before or after the
+// current one.
+func (pom *partitionOffsetManager) setOffset(offset int64, metadata string) {
+	pom.lock.Lock()
+	defer pom.lock.Unlock()
+
+	pom.offset = offset
+	pom.metadata = metadata
+	pom.dirty = true
+}
+
 func (pom *partitionOffsetManager) updateCommitted(offset int64, metadata string) {
 	pom.lock.Lock()
 	defer pom.lock.Unlock()
@@ -582,7 +679,7 @@ func (pom *partitionOffsetManager) handleError(err error) {
 	if pom.parent.conf.Consumer.Return.Errors {
 		pom.errors <- cErr
 	} else {
-		Logger.Println(cErr)
+		pom.parent.conf.logger(LogField{LogFieldGroup, pom.parent.group}, LogField{LogFieldTopic, pom.topic}, LogField{LogFieldPartition, pom.partition}).Errorf("%s", cErr)
 	}
 }
 
@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -568,3 +569,141 @@ func TestAbortPartitionOffsetManager(t *testing.T) {
 	broker.Close()
 	safeClose(t, testClient)
 }
+
+type memoryOffsetStore struct {
+	lock    sync.Mutex
+	offsets map[string]map[int32]int64
+	saves   int
+}
+
+func (s *memoryOffsetStore) Load(group, topic string, partition int32) (int64, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if offset, ok := s.offsets[topic][partition]; ok {
+		return offset, group + "_meta", nil
+	}
+	return -1, "", nil
+}
+
+func (s *memoryOffsetStore) Save(group, topic string, partition int32, offset int64, metadata string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.offsets[topic] == nil {
+		s.offsets[topic] = make(map[int32]int64)
+	}
+	s.offsets[topic][partition] = offset
+	s.saves++
+	return nil
+}
+
+func TestOffsetManagerExternalStore(t *testing.T) {
+	store := &memoryOffsetStore{offsets: map[string]map[int32]int64{"my_topic": {0: 5}}}
+	config := NewTestConfig()
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Offsets.Store = store
+
+	// the coordinator must never be asked for offsets
+	broker := NewMockBroker(t, 1)
+	seedMeta := new(MetadataResponse)
+	seedMeta.AddTopicPartition("my_topic", 0, 1, []int32{}, []int32{}, []int32{}, ErrNoError)
+	broker.Returns(seedMeta)
+
+	testClient, err := NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	om, err := NewOffsetManagerFromClient("group", testClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pom, err := om.ManagePartition("my_topic", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset, meta := pom.NextOffset()
+	if offset != 5 || meta != "group_meta" {
+		t.Errorf("Expected offset 5 with metadata \"group_meta\". Actual: %v %q", offset, meta)
+	}
+
+	pom.MarkOffset(10, "modified_meta")
+	om.Commit()
+
+	store.lock.Lock()
+	if store.offsets["my_topic"][0] != 10 || store.saves != 1 {
+		t.Errorf("Expected a single save of offset 10. Actual: %v after %d saves", store.offsets["my_topic"][0], store.saves)
+	}
+	store.lock.Unlock()
+
+	// nothing is dirty, so nothing should be saved
+	om.Commit()
+	store.lock.Lock()
+	if store.saves != 1 {
+		t.Errorf("Expected no further saves. Actual: %d", store.saves)
+	}
+	store.lock.Unlock()
+
+	// !! om must be closed before the pom so pom.release() is called before pom.Close()
+	safeClose(t, om)
+	safeClose(t, pom)
+	safeClose(t, testClient)
+	broker.Close()
+}
+
+type commitRecordingInterceptor struct {
+	lock      sync.Mutex
+	committed []map[string]map[int32]int64
+}
+
+func (i *commitRecordingInterceptor) OnConsume(*ConsumerMessage) {}
+
+func (i *commitRecordingInterceptor) OnCommit(offsets map[string]map[int32]int64) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.committed = append(i.committed, offsets)
+}
+
+func TestOffsetManagerCommitInterceptor(t *testing.T) {
+	interceptor := &commitRecordingInterceptor{}
+	config := NewTestConfig()
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Interceptors = []ConsumerInterceptor{interceptor}
+	om, testClient, broker, coordinator := initOffsetManagerWithBackoffFunc(t, 0, nil, config)
+	pom := initPartitionOffsetManager(t, om, coordinator, 5, "meta")
+
+	ocResponse := new(OffsetCommitResponse)
+	ocResponse.AddError("my_topic", 0, ErrOffsetMetadataTooLarge)
+	coordinator.Returns(ocResponse)
+
+	pom.MarkOffset(100, "meta")
+	om.Commit()
+
+	interceptor.lock.Lock()
+	if len(interceptor.committed) != 0 {
+		t.Errorf("Expected no commit to be intercepted after a failure. Actual: %v", interceptor.committed)
+	}
+	interceptor.lock.Unlock()
+
+	ocResponse = new(OffsetCommitResponse)
+	ocResponse.AddError("my_topic", 0, ErrNoError)
+	coordinator.Returns(ocResponse)
+
+	om.Commit()
+
+	interceptor.lock.Lock()
+	if len(interceptor.committed) != 1 || interceptor.committed[0]["my_topic"][0] != 100 {
+		t.Errorf("Expected offset 100 to be intercepted once. Actual: %v", interceptor.committed)
+	}
+	interceptor.lock.Unlock()
+
+	// !! om must be closed before the pom so pom.release() is called before pom.Close()
+	safeClose(t, om)
+	safeClose(t, pom)
+	safeClose(t, testClient)
+	broker.Close()
+	coordinator.Close()
+}
@@ -183,7 +183,7 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 				}
 				payload, err := encode(set.recordsToSend.MsgSet, ps.parent.conf.MetricRegistry)
 				if err != nil {
-					Logger.Println(err) // if this happens, it's basically our fault.
+					ps.parent.conf.logger().Errorf("%s", err) // if this happens, it's basically our fault.
 					panic(err)
 				}
 				compMsg := &Message{
@@ -0,0 +1,297 @@
+/*
+Package prometheus exposes the metrics Sarama records in its go-metrics
+registry (Config.MetricRegistry) in the Prometheus text exposition format.
+
+Sarama encodes the broker and topic a metric relates to in the metric name
+itself, e.g. "request-latency-in-ms-for-broker-3" or
+"record-send-rate-for-topic-my_topic". The exporter parses these suffixes back
+into "broker" and "topic" labels so that all instances of a metric are exported
+as a single Prometheus metric family:
+
+	sarama_request_latency_in_ms{broker="3",quantile="0.5"} 12
+
+The aggregate Sarama records over all brokers or topics, e.g. "request-rate",
+is exported in the same family with the label set to "all", so that it can be
+excluded when aggregating the family:
+
+	sum(sarama_request_rate_total{broker!="all"})
+
+Histograms and timers are exported as summaries, meters as counters of the
+total number of events marked, and go-metrics counters and gauges as gauges
+since go-metrics counters may be decremented.
+
+NOTE: topic names are exported as Sarama records them, that is with dots
+replaced by underscores. A topic named "all" cannot be told apart from the
+aggregate over all topics.
+*/
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// DefaultNamespace is the prefix prepended to every exported metric name.
+const DefaultNamespace = "sarama"
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Quantiles are the quantiles exported for every histogram and timer.
+var Quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+var (
+	brokerSuffix = regexp.MustCompile(`^(.+)-for-broker-(-?\d+)$`)
+	topicSuffix  = regexp.MustCompile(`^(.+)-for-topic-(.+)$`)
+	invalidChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+)
+
+// Exporter renders a go-metrics registry in the Prometheus text exposition
+// format. It implements http.Handler so it can be mounted directly on a mux.
+type Exporter struct {
+	// Registry is the registry to export, usually Config.MetricRegistry.
+	Registry metrics.Registry
+	// Namespace is prepended to every metric name, separated by an
+	// underscore. Leave empty to export the bare metric names.
+	Namespace string
+}
+
+// NewExporter returns an Exporter for the given registry using the
+// DefaultNamespace.
+func NewExporter(r metrics.Registry) *Exporter {
+	return &Exporter{Registry: r, Namespace: DefaultNamespace}
+}
+
+// Handler returns an http.Handler serving the given registry in the
+// Prometheus text exposition format.
+func Handler(r metrics.Registry) http.Handler {
+	return NewExporter(r)
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	// render the whole response first, so that a failure can still be
+	// reported with an error status
+	var buf bytes.Buffer
+	if err := e.Write(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// Write renders the registry to w. Metric families are written sorted by
+// name, and series within a family sorted by their labels, so the output is
+// stable between calls.
+func (e *Exporter) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range e.collect() {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+type label struct {
+	name, value string
+}
+
+type series struct {
+	labels []label
+	metric interface{}
+}
+
+type family struct {
+	name   string
+	kind   string
+	series []series
+}
+
+// collect groups the registered metrics into families keyed by their
+// exported name and type.
+func (e *Exporter) collect() []*family {
+	families := make(map[string]*family)
+
+	e.Registry.Each(func(name string, i interface{}) {
+		kind := kindOf(i)
+		if kind == "" {
+			return
+		}
+
+		base, labels := parseName(name)
+		fname := e.metricName(base)
+		if kind == "counter" && !strings.HasSuffix(fname, "_total") {
+			fname += "_total"
+		}
+
+		f := families[fname]
+		if f == nil {
+			f = &family{name: fname, kind: kind}
+			families[fname] = f
+		} else if f.kind != kind {
+			// the same name is used for different metric types, which
+			// Prometheus cannot represent; keep the first one seen
+			return
+		}
+		f.series = append(f.series, series{labels: labels, metric: i})
+	})
+
+	result := make([]*family, 0, len(families))
+	for _, f := range families {
+		labelAggregate(f)
+		sort.Slice(f.series, func(i, j int) bool {
+			return labelString(f.series[i].labels) < labelString(f.series[j].labels)
+		})
+		result = append(result, f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// labelAggregate sets the labels of the series of f without any, i.e. the
+// aggregate over all brokers or topics, to "all" when other series of f have
+// them, so that the aggregate is not counted twice when summing the family.
+func labelAggregate(f *family) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, s := range f.series {
+		for _, l := range s.labels {
+			if !seen[l.name] {
+				seen[l.name] = true
+				names = append(names, l.name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	for i := range f.series {
+		if len(f.series[i].labels) > 0 {
+			continue
+		}
+		labels := make([]label, len(names))
+		for j, name := range names {
+			labels[j] = label{name, "all"}
+		}
+		f.series[i].labels = labels
+	}
+}
+
+func (e *Exporter) metricName(base string) string {
+	name := invalidChars.ReplaceAllString(base, "_")
+	if e.Namespace != "" {
+		name = e.Namespace + "_" + name
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// parseName splits a Sarama metric name into its base name and the broker or
+// topic labels encoded in its suffix.
+func parseName(name string) (string, []label) {
+	if m := brokerSuffix.FindStringSubmatch(name); m != nil {
+		return m[1], []label{{"broker", m[2]}}
+	}
+	if m := topicSuffix.FindStringSubmatch(name); m != nil {
+		return m[1], []label{{"topic", m[2]}}
+	}
+	return name, nil
+}
+
+func kindOf(i interface{}) string {
+	switch i.(type) {
+	case metrics.Meter:
+		return "counter"
+	case metrics.Counter, metrics.Gauge, metrics.GaugeFloat64:
+		// go-metrics counters can be decremented (e.g. requests-in-flight)
+		// so they map to Prometheus gauges
+		return "gauge"
+	case metrics.Histogram, metrics.Timer:
+		return "summary"
+	default:
+		return ""
+	}
+}
+
+func (f *family) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	for _, s := range f.series {
+		switch m := s.metric.(type) {
+		case metrics.Counter:
+			writeSample(w, f.name, s.labels, float64(m.Count()))
+		case metrics.Meter:
+			writeSample(w, f.name, s.labels, float64(m.Snapshot().Count()))
+		case metrics.Gauge:
+			writeSample(w, f.name, s.labels, float64(m.Value()))
+		case metrics.GaugeFloat64:
+			writeSample(w, f.name, s.labels, m.Value())
+		case metrics.Histogram:
+			h := m.Snapshot()
+			writeSummary(w, f.name, s.labels, h.Percentiles(Quantiles), float64(h.Sum()), h.Count())
+		case metrics.Timer:
+			t := m.Snapshot()
+			writeSummary(w, f.name, s.labels, t.Percentiles(Quantiles), float64(t.Sum()), t.Count())
+		}
+	}
+}
+
+func writeSummary(w *bufio.Writer, name string, labels []label, values []float64, sum float64, count int64) {
+	for i, q := range Quantiles {
+		ql := append(labels[:len(labels):len(labels)], label{"quantile", formatFloat(q)})
+		writeSample(w, name, ql, values[i])
+	}
+	writeSample(w, name+"_sum", labels, sum)
+	writeSample(w, name+"_count", labels, float64(count))
+}
+
+func writeSample(w *bufio.Writer, name string, labels []label, value float64) {
+	w.WriteString(name)
+	w.WriteString(labelString(labels))
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func labelString(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.name + `="` + escapeLabelValue(l.value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
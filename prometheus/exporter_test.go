@@ -0,0 +1,76 @@
+package prometheus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+func TestExporterLabels(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterMeter("request-rate", r).Mark(3)
+	metrics.GetOrRegisterMeter("request-rate-for-broker-1", r).Mark(1)
+	metrics.GetOrRegisterMeter("request-rate-for-broker-2", r).Mark(2)
+	metrics.GetOrRegisterMeter("record-send-rate", r).Mark(5)
+	metrics.GetOrRegisterMeter("record-send-rate-for-topic-my_topic", r).Mark(5)
+	metrics.GetOrRegisterCounter("requests-in-flight-for-broker-1", r).Inc(4)
+	metrics.GetOrRegisterGauge("some-gauge", r).Update(7)
+
+	var buf bytes.Buffer
+	if err := NewExporter(r).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# TYPE sarama_record_send_rate_total counter
+sarama_record_send_rate_total{topic="all"} 5
+sarama_record_send_rate_total{topic="my_topic"} 5
+# TYPE sarama_request_rate_total counter
+sarama_request_rate_total{broker="1"} 1
+sarama_request_rate_total{broker="2"} 2
+sarama_request_rate_total{broker="all"} 3
+# TYPE sarama_requests_in_flight gauge
+sarama_requests_in_flight{broker="1"} 4
+# TYPE sarama_some_gauge gauge
+sarama_some_gauge 7
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestExporterHistogram(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := metrics.GetOrRegisterHistogram("request-latency-in-ms-for-broker-3", r, metrics.NewUniformSample(10))
+	for i := int64(1); i <= 4; i++ {
+		h.Update(i)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(r).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, line := range []string{
+		"# TYPE sarama_request_latency_in_ms summary",
+		`sarama_request_latency_in_ms{broker="3",quantile="0.5"} 2.5`,
+		`sarama_request_latency_in_ms{broker="3",quantile="0.999"} 4`,
+		`sarama_request_latency_in_ms_sum{broker="3"} 10`,
+		`sarama_request_latency_in_ms_count{broker="3"} 4`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if v := escapeLabelValue("a\"b\\c\nd"); v != `a\"b\\c\nd` {
+		t.Errorf("unexpected escaped value %q", v)
+	}
+}
@@ -0,0 +1,238 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Response is a Kafka response decoded by DecodeResponse or a StreamDecoder,
+// along with its header.
+type Response struct {
+	// APIKey and APIVersion identify the type and version of Body, and are
+	// those of the request it responds to.
+	APIKey     int16
+	APIVersion int16
+	// CorrelationID is that of the request it responds to.
+	CorrelationID int32
+	// Body is the decoded response, for example a *MetadataResponse.
+	Body ProtocolBody
+}
+
+// DecodeRequest reads a single request, including its length prefix, from r
+// and decodes it. RemoteAddr is left unset in the returned Request.
+func DecodeRequest(r io.Reader) (*Request, error) {
+	req, _, err := decodeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		APIKey:        req.body.key(),
+		APIVersion:    req.body.version(),
+		CorrelationID: req.correlationID,
+		ClientID:      req.clientID,
+		Body:          req.body,
+	}, nil
+}
+
+// DecodeResponse reads a single response, including its length prefix, from r
+// and decodes it as the response to a request of the given API key and
+// version. Since the response header does not carry them, they must be known
+// from the corresponding request; see StreamDecoder to keep track of them.
+func DecodeResponse(r io.Reader, apiKey, apiVersion int16) (*Response, error) {
+	buf, err := readResponseFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponseFrame(buf, apiKey, apiVersion)
+}
+
+// StreamDecoder decodes both directions of a Kafka connection, such as
+// extracted from a packet capture, and matches each response to the request
+// it answers by correlation ID. Requests must be decoded before their
+// responses, but the two directions need not be interleaved.
+type StreamDecoder struct {
+	requests map[int32]*Request
+}
+
+// NewStreamDecoder returns a StreamDecoder with no outstanding requests.
+func NewStreamDecoder() *StreamDecoder {
+	return &StreamDecoder{requests: make(map[int32]*Request)}
+}
+
+// DecodeRequest reads and decodes a single request sent by the client, as
+// DecodeRequest does, and records it until its response is decoded.
+func (d *StreamDecoder) DecodeRequest(r io.Reader) (*Request, error) {
+	req, err := DecodeRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	d.requests[req.CorrelationID] = req
+	return req, nil
+}
+
+// DecodeResponse reads and decodes a single response sent by the broker, and
+// returns it along with the request it answers. If that request was not
+// decoded previously, the response is skipped and an error is returned, so
+// that decoding can carry on with the next one.
+func (d *StreamDecoder) DecodeResponse(r io.Reader) (*Response, *Request, error) {
+	buf, err := readResponseFrame(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	correlationID := int32(binary.BigEndian.Uint32(buf[responseLengthSize:]))
+	req, ok := d.requests[correlationID]
+	if !ok {
+		return nil, nil, PacketDecodingError{fmt.Sprintf("no request with correlation ID %d", correlationID)}
+	}
+	delete(d.requests, correlationID)
+
+	res, err := decodeResponseFrame(buf, req.APIKey, req.APIVersion)
+	if err != nil {
+		return nil, req, err
+	}
+	return res, req, nil
+}
+
+// Pending returns the requests decoded so far whose response has not been
+// decoded yet.
+func (d *StreamDecoder) Pending() []*Request {
+	pending := make([]*Request, 0, len(d.requests))
+	for _, req := range d.requests {
+		pending = append(pending, req)
+	}
+	return pending
+}
+
+// readResponseFrame returns the next response read from r, including its
+// length prefix.
+func readResponseFrame(r io.Reader) ([]byte, error) {
+	lengthBytes := make([]byte, responseLengthSize)
+	if _, err := io.ReadFull(r, lengthBytes); err != nil {
+		return nil, err
+	}
+
+	length := int32(binary.BigEndian.Uint32(lengthBytes))
+	if length <= correlationIDSize || length > MaxResponseSize {
+		return nil, PacketDecodingError{fmt.Sprintf("message of length %d too large or too small", length)}
+	}
+
+	buf := make([]byte, responseLengthSize+int(length))
+	copy(buf, lengthBytes)
+	if _, err := io.ReadFull(r, buf[responseLengthSize:]); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func decodeResponseFrame(buf []byte, apiKey, apiVersion int16) (*Response, error) {
+	resBody := allocateResponseBody(apiKey, apiVersion)
+	if resBody == nil {
+		return nil, PacketDecodingError{fmt.Sprintf("unknown response key (%d)", apiKey)}
+	}
+
+	res := &response{headerVersion: resBody.headerVersion(), body: resBody}
+	if err := versionedDecode(buf, res, apiVersion); err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		APIKey:        apiKey,
+		APIVersion:    apiVersion,
+		CorrelationID: res.header.correlationID,
+		Body:          resBody,
+	}, nil
+}
+
+// response is a response frame, made of its length prefix, header and body.
+type response struct {
+	headerVersion int16
+	header        responseHeader
+	body          protocolBody
+}
+
+func (r *response) decode(pd packetDecoder, version int16) error {
+	if err := r.header.decode(pd, r.headerVersion); err != nil {
+		return err
+	}
+	return r.body.decode(pd, version)
+}
+
+func allocateResponseBody(key, version int16) protocolBody {
+	switch key {
+	case 0:
+		return &ProduceResponse{}
+	case 1:
+		return &FetchResponse{}
+	case 2:
+		return &OffsetResponse{}
+	case 3:
+		return &MetadataResponse{}
+	case 8:
+		return &OffsetCommitResponse{}
+	case 9:
+		return &OffsetFetchResponse{}
+	case 10:
+		return &FindCoordinatorResponse{}
+	case 11:
+		return &JoinGroupResponse{}
+	case 12:
+		return &HeartbeatResponse{}
+	case 13:
+		return &LeaveGroupResponse{}
+	case 14:
+		return &SyncGroupResponse{}
+	case 15:
+		return &DescribeGroupsResponse{}
+	case 16:
+		return &ListGroupsResponse{}
+	case 17:
+		return &SaslHandshakeResponse{}
+	case 18:
+		return &ApiVersionsResponse{}
+	case 19:
+		return &CreateTopicsResponse{}
+	case 20:
+		return &DeleteTopicsResponse{}
+	case 21:
+		return &DeleteRecordsResponse{}
+	case 22:
+		return &InitProducerIDResponse{}
+	case 24:
+		return &AddPartitionsToTxnResponse{}
+	case 25:
+		return &AddOffsetsToTxnResponse{}
+	case 26:
+		return &EndTxnResponse{}
+	case 28:
+		return &TxnOffsetCommitResponse{}
+	case 29:
+		return &DescribeAclsResponse{}
+	case 30:
+		return &CreateAclsResponse{}
+	case 31:
+		return &DeleteAclsResponse{}
+	case 32:
+		return &DescribeConfigsResponse{}
+	case 33:
+		return &AlterConfigsResponse{}
+	case 35:
+		return &DescribeLogDirsResponse{}
+	case 36:
+		return &SaslAuthenticateResponse{}
+	case 37:
+		return &CreatePartitionsResponse{}
+	case 42:
+		return &DeleteGroupsResponse{}
+	case 45:
+		return &AlterPartitionReassignmentsResponse{}
+	case 46:
+		return &ListPartitionReassignmentsResponse{}
+	case 48:
+		return &DescribeClientQuotasResponse{}
+	case 49:
+		return &AlterClientQuotasResponse{}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package sarama
+
+import (
+	"bytes"
+	"testing"
+)
+
+func encodeTestResponse(t *testing.T, correlationID int32, res protocolBody) []byte {
+	t.Helper()
+	body, err := encode(res, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(encodeResponseHeader(res.headerVersion(), correlationID, uint32(len(body))), body...)
+}
+
+func TestStreamDecoder(t *testing.T) {
+	var requests, responses bytes.Buffer
+	for i, body := range []protocolBody{
+		&MetadataRequest{Version: 1, Topics: []string{"my_topic"}},
+		&ApiVersionsRequest{},
+	} {
+		buf, err := encode(&request{correlationID: int32(i), clientID: "decoder_test", body: body}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requests.Write(buf)
+	}
+
+	// responses are out of order, and one of them answers an unknown request
+	metadata := &MetadataResponse{Version: 1, ControllerID: 1}
+	metadata.AddBroker("localhost:9092", 1)
+	metadata.AddTopicPartition("my_topic", 0, 1, nil, nil, nil, ErrNoError)
+	responses.Write(encodeTestResponse(t, 1, &ApiVersionsResponse{ApiVersions: []*ApiVersionsResponseBlock{{ApiKey: 3, MaxVersion: 9}}}))
+	responses.Write(encodeTestResponse(t, 42, &HeartbeatResponse{}))
+	responses.Write(encodeTestResponse(t, 0, metadata))
+
+	d := NewStreamDecoder()
+	for i := 0; i < 2; i++ {
+		req, err := d.DecodeRequest(&requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.CorrelationID != int32(i) || req.ClientID != "decoder_test" {
+			t.Errorf("Unexpected request header %+v", req)
+		}
+	}
+
+	res, req, err := d.DecodeResponse(&responses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := req.Body.(*ApiVersionsRequest); !ok {
+		t.Errorf("Expected the response to be matched to the ApiVersionsRequest, got %T", req.Body)
+	}
+	if body, ok := res.Body.(*ApiVersionsResponse); !ok || len(body.ApiVersions) != 1 || body.ApiVersions[0].MaxVersion != 9 {
+		t.Errorf("Unexpected response %#v", res.Body)
+	}
+
+	if _, _, err := d.DecodeResponse(&responses); err == nil {
+		t.Error("Expected an error decoding a response to an unknown request")
+	}
+
+	res, req, err = d.DecodeResponse(&responses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.APIKey != 3 || req.APIVersion != 1 || res.APIKey != 3 || res.CorrelationID != 0 {
+		t.Errorf("Unexpected request %+v and response %+v", req, res)
+	}
+	if body, ok := res.Body.(*MetadataResponse); !ok || body.Version != 1 || len(body.Topics) != 1 || body.Topics[0].Name != "my_topic" {
+		t.Errorf("Unexpected response %#v", res.Body)
+	}
+
+	if len(d.Pending()) != 0 {
+		t.Errorf("Expected no pending requests, got %v", d.Pending())
+	}
+}
+
+func TestDecodeResponseFlexibleHeader(t *testing.T) {
+	buf := encodeTestResponse(t, 5, &ListPartitionReassignmentsResponse{Version: 0, ErrorCode: ErrNoError})
+
+	res, err := DecodeResponse(bytes.NewReader(buf), 46, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.Body.(*ListPartitionReassignmentsResponse); !ok || res.CorrelationID != 5 {
+		t.Errorf("Unexpected response %+v", res)
+	}
+}
@@ -0,0 +1,82 @@
+package sarama
+
+import "encoding/json"
+
+// The requests below keep their payload in unexported fields, which
+// encoding/json would otherwise leave out. Their MarshalJSON methods add it
+// under the name of the method used to build it, so that decoded requests
+// can be printed in full, e.g. by the kafka-protocol-decoder tool.
+
+// MarshalJSON implements json.Marshaler.
+func (r *ProduceRequest) MarshalJSON() ([]byte, error) {
+	type fields ProduceRequest
+	return json.Marshal(struct {
+		fields
+		Records map[string]map[int32]Records
+	}{fields(*r), r.records})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *FetchRequest) MarshalJSON() ([]byte, error) {
+	type fields FetchRequest
+	return json.Marshal(struct {
+		fields
+		Blocks    map[string]map[int32]*fetchRequestBlock
+		Forgotten map[string][]int32
+	}{fields(*r), r.blocks, r.forgotten})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b *fetchRequestBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		CurrentLeaderEpoch int32
+		FetchOffset        int64
+		LogStartOffset     int64
+		MaxBytes           int32
+	}{b.currentLeaderEpoch, b.fetchOffset, b.logStartOffset, b.maxBytes})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *OffsetRequest) MarshalJSON() ([]byte, error) {
+	type fields OffsetRequest
+	return json.Marshal(struct {
+		fields
+		ReplicaID int32
+		Blocks    map[string]map[int32]*offsetRequestBlock
+	}{fields(*r), r.ReplicaID(), r.blocks})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b *offsetRequestBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Time       int64
+		MaxOffsets int32
+	}{b.time, b.maxOffsets})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *OffsetCommitRequest) MarshalJSON() ([]byte, error) {
+	type fields OffsetCommitRequest
+	return json.Marshal(struct {
+		fields
+		Blocks map[string]map[int32]*offsetCommitRequestBlock
+	}{fields(*r), r.blocks})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b *offsetCommitRequestBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Offset    int64
+		Timestamp int64
+		Metadata  string
+	}{b.offset, b.timestamp, b.metadata})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *OffsetFetchRequest) MarshalJSON() ([]byte, error) {
+	type fields OffsetFetchRequest
+	return json.Marshal(struct {
+		fields
+		Partitions map[string][]int32
+	}{fields(*r), r.partitions})
+}
@@ -0,0 +1,34 @@
+package sarama
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestMarshalJSON(t *testing.T) {
+	offsetRequest := &OffsetRequest{}
+	offsetRequest.AddBlock("my_topic", 0, OffsetNewest, 1)
+
+	offsetCommitRequest := &OffsetCommitRequest{Version: 1, ConsumerGroup: "my_group"}
+	offsetCommitRequest.AddBlock("my_topic", 0, 42, 0, "meta")
+
+	offsetFetchRequest := &OffsetFetchRequest{Version: 1, ConsumerGroup: "my_group"}
+	offsetFetchRequest.AddPartition("my_topic", 0)
+
+	for _, tc := range []struct {
+		req      interface{}
+		expected string
+	}{
+		{offsetRequest, `{"Version":0,"ReplicaID":-1,"Blocks":{"my_topic":{"0":{"Time":-1,"MaxOffsets":1}}}}`},
+		{offsetCommitRequest, `{"ConsumerGroup":"my_group","ConsumerGroupGeneration":0,"ConsumerID":"","RetentionTime":0,"Version":1,"Blocks":{"my_topic":{"0":{"Offset":42,"Timestamp":0,"Metadata":"meta"}}}}`},
+		{offsetFetchRequest, `{"Version":1,"ConsumerGroup":"my_group","Partitions":{"my_topic":[0]}}`},
+	} {
+		buf, err := json.Marshal(tc.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != tc.expected {
+			t.Errorf("Unexpected JSON for %T:\n%s\nexpected:\n%s", tc.req, buf, tc.expected)
+		}
+	}
+}
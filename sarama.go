@@ -81,7 +81,8 @@ import (
 var (
 	// Logger is the instance of a StdLogger interface that Sarama writes connection
 	// management events to. By default it is set to discard all log messages via ioutil.Discard,
-	// but you can set it to redirect wherever you want.
+	// but you can set it to redirect wherever you want. Config.Logger takes precedence over it for the
+	// clients created with that Config.
 	Logger StdLogger = log.New(ioutil.Discard, "[Sarama] ", log.LstdFlags)
 
 	// PanicHandler is called for recovering from panics spawned internally to the library (and thus
@@ -1,9 +1,14 @@
 package sarama
 
 type SaslAuthenticateResponse struct {
+	// Version defines the protocol version to use for encode and decode
+	Version       int16
 	Err           KError
 	ErrorMessage  *string
 	SaslAuthBytes []byte
+	// SessionLifetimeMs is the number of milliseconds after which the broker
+	// expects the client to re-authenticate, 0 if it does not (Version 1+)
+	SessionLifetimeMs int64
 }
 
 func (r *SaslAuthenticateResponse) encode(pe packetEncoder) error {
@@ -11,10 +16,17 @@ func (r *SaslAuthenticateResponse) encode(pe packetEncoder) error {
 	if err := pe.putNullableString(r.ErrorMessage); err != nil {
 		return err
 	}
-	return pe.putBytes(r.SaslAuthBytes)
+	if err := pe.putBytes(r.SaslAuthBytes); err != nil {
+		return err
+	}
+	if r.Version >= 1 {
+		pe.putInt64(r.SessionLifetimeMs)
+	}
+	return nil
 }
 
 func (r *SaslAuthenticateResponse) decode(pd packetDecoder, version int16) error {
+	r.Version = version
 	kerr, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -26,9 +38,17 @@ func (r *SaslAuthenticateResponse) decode(pd packetDecoder, version int16) error
 		return err
 	}
 
-	r.SaslAuthBytes, err = pd.getBytes()
+	if r.SaslAuthBytes, err = pd.getBytes(); err != nil {
+		return err
+	}
 
-	return err
+	if version >= 1 {
+		if r.SessionLifetimeMs, err = pd.getInt64(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *SaslAuthenticateResponse) key() int16 {
@@ -36,7 +56,7 @@ func (r *SaslAuthenticateResponse) key() int16 {
 }
 
 func (r *SaslAuthenticateResponse) version() int16 {
-	return 0
+	return r.Version
 }
 
 func (r *SaslAuthenticateResponse) headerVersion() int16 {
@@ -44,5 +64,10 @@ func (r *SaslAuthenticateResponse) headerVersion() int16 {
 }
 
 func (r *SaslAuthenticateResponse) requiredVersion() KafkaVersion {
-	return V1_0_0_0
+	switch r.Version {
+	case 1:
+		return V2_2_0_0
+	default:
+		return V1_0_0_0
+	}
 }
@@ -8,6 +8,12 @@ var (
 		0, 3, 'e', 'r', 'r',
 		0, 0, 0, 3, 'm', 's', 'g',
 	}
+	saslAuthenticatResponseV1 = []byte{
+		0, 0,
+		255, 255,
+		0, 0, 0, 3, 'm', 's', 'g',
+		0, 0, 0, 0, 0, 0, 1, 244,
+	}
 )
 
 func TestSaslAuthenticateResponse(t *testing.T) {
@@ -19,3 +25,13 @@ func TestSaslAuthenticateResponse(t *testing.T) {
 
 	testResponse(t, "authenticate response", response, saslAuthenticatResponseErr)
 }
+
+func TestSaslAuthenticateResponseV1(t *testing.T) {
+	response := new(SaslAuthenticateResponse)
+	response.Version = 1
+	response.Err = ErrNoError
+	response.SaslAuthBytes = []byte(`msg`)
+	response.SessionLifetimeMs = 500
+
+	testResponse(t, "authenticate response v1", response, saslAuthenticatResponseV1)
+}
@@ -0,0 +1,178 @@
+package schemaregistry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Schema types, as named by the registry.
+const (
+	TypeAvro     = "AVRO"
+	TypeJSON     = "JSON"
+	TypeProtobuf = "PROTOBUF"
+)
+
+// ErrSchemaNotFound is returned by a Client when no schema is registered with
+// the requested ID.
+var ErrSchemaNotFound = errors.New("schemaregistry: schema not found")
+
+// Schema is a schema stored in a registry.
+type Schema struct {
+	// Type is one of TypeAvro, TypeJSON and TypeProtobuf. The registry
+	// treats an empty type as TypeAvro.
+	Type string
+	// Schema is the definition of the schema, e.g. a JSON Schema document.
+	Schema string
+}
+
+// Client is the interface to a schema registry used by serializers and
+// deserializers. Implementations must be safe for concurrent use.
+type Client interface {
+	// Register registers schema under subject, unless it is already, and
+	// returns its ID.
+	Register(subject string, schema Schema) (int, error)
+	// SchemaByID returns the schema registered with the given ID, or
+	// ErrSchemaNotFound.
+	SchemaByID(id int) (Schema, error)
+}
+
+// TopicNameStrategy returns the subject under which the schemas of the keys or
+// values of the messages of topic are registered by default, that is
+// "<topic>-key" or "<topic>-value".
+func TopicNameStrategy(topic string, isKey bool) string {
+	if isKey {
+		return topic + "-key"
+	}
+	return topic + "-value"
+}
+
+// NewCachedClient returns a Client caching the results of client, which are
+// immutable in a schema registry.
+func NewCachedClient(client Client) Client {
+	return &cachedClient{
+		client:  client,
+		ids:     make(map[subjectSchema]int),
+		schemas: make(map[int]Schema),
+	}
+}
+
+type subjectSchema struct {
+	subject string
+	schema  Schema
+}
+
+type cachedClient struct {
+	client Client
+
+	lock    sync.RWMutex
+	ids     map[subjectSchema]int
+	schemas map[int]Schema
+}
+
+func (c *cachedClient) Register(subject string, schema Schema) (int, error) {
+	key := subjectSchema{subject, schema}
+
+	c.lock.RLock()
+	id, ok := c.ids[key]
+	c.lock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := c.client.Register(subject, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	c.lock.Lock()
+	c.ids[key] = id
+	c.schemas[id] = schema
+	c.lock.Unlock()
+	return id, nil
+}
+
+func (c *cachedClient) SchemaByID(id int) (Schema, error) {
+	c.lock.RLock()
+	schema, ok := c.schemas[id]
+	c.lock.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := c.client.SchemaByID(id)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	c.lock.Lock()
+	c.schemas[id] = schema
+	c.lock.Unlock()
+	return schema, nil
+}
+
+// MemoryRegistry is a Client storing schemas in memory, standing in for a
+// registry server in tests. Like a registry, it assigns the same ID to
+// identical schemas registered under different subjects.
+type MemoryRegistry struct {
+	lock     sync.Mutex
+	schemas  []Schema
+	subjects map[string][]int
+}
+
+// NewMemoryRegistry returns an empty MemoryRegistry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{subjects: make(map[string][]int)}
+}
+
+// Register implements Client.
+func (r *MemoryRegistry) Register(subject string, schema Schema) (int, error) {
+	if schema.Type == "" {
+		schema.Type = TypeAvro
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	id := r.idOf(schema)
+	if id == 0 {
+		r.schemas = append(r.schemas, schema)
+		id = len(r.schemas)
+	}
+	for _, registered := range r.subjects[subject] {
+		if registered == id {
+			return id, nil
+		}
+	}
+	r.subjects[subject] = append(r.subjects[subject], id)
+	return id, nil
+}
+
+// SchemaByID implements Client.
+func (r *MemoryRegistry) SchemaByID(id int) (Schema, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if id < 1 || id > len(r.schemas) {
+		return Schema{}, fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+	}
+	return r.schemas[id-1], nil
+}
+
+// Versions returns the IDs of the schemas registered under subject, in the
+// order they were registered.
+func (r *MemoryRegistry) Versions(subject string) []int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]int(nil), r.subjects[subject]...)
+}
+
+// idOf returns the ID of schema, or 0 if it is not registered.
+func (r *MemoryRegistry) idOf(schema Schema) int {
+	for i, registered := range r.schemas {
+		if registered == schema {
+			return i + 1
+		}
+	}
+	return 0
+}
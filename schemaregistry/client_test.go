@@ -0,0 +1,66 @@
+package schemaregistry
+
+import (
+	"errors"
+	"testing"
+)
+
+type countingClient struct {
+	Client
+	registers, lookups int
+}
+
+func (c *countingClient) Register(subject string, schema Schema) (int, error) {
+	c.registers++
+	return c.Client.Register(subject, schema)
+}
+
+func (c *countingClient) SchemaByID(id int) (Schema, error) {
+	c.lookups++
+	return c.Client.SchemaByID(id)
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	registry := NewMemoryRegistry()
+	schema := Schema{Type: TypeJSON, Schema: testJSONSchema}
+
+	id1, _ := registry.Register("a-value", schema)
+	id2, _ := registry.Register("b-value", schema)
+	id3, _ := registry.Register("a-value", Schema{Type: TypeJSON, Schema: `{}`})
+	if id1 != id2 || id1 == id3 {
+		t.Errorf("Expected identical schemas to share their ID, got %d %d %d", id1, id2, id3)
+	}
+	if versions := registry.Versions("a-value"); len(versions) != 2 {
+		t.Errorf("Expected 2 versions of a-value, got %v", versions)
+	}
+
+	if got, err := registry.SchemaByID(id1); err != nil || got != schema {
+		t.Errorf("Unexpected schema %+v, err=%v", got, err)
+	}
+	if _, err := registry.SchemaByID(42); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("Expected ErrSchemaNotFound, got %v", err)
+	}
+}
+
+func TestCachedClient(t *testing.T) {
+	counting := &countingClient{Client: NewMemoryRegistry()}
+	client := NewCachedClient(counting)
+	schema := Schema{Type: TypeJSON, Schema: testJSONSchema}
+
+	for i := 0; i < 3; i++ {
+		id, err := client.Register("a-value", schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.SchemaByID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counting.registers != 1 || counting.lookups != 0 {
+		t.Errorf("Expected a single request to the registry, got %d registrations and %d lookups", counting.registers, counting.lookups)
+	}
+
+	if _, err := client.SchemaByID(42); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("Expected ErrSchemaNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// HTTPClient is a Client for the REST API of a schema registry server.
+type HTTPClient struct {
+	// BaseURL is the URL of the registry, e.g. "http://localhost:8081".
+	BaseURL string
+	// HTTPClient performs the requests; http.DefaultClient is used if nil.
+	// Set its Transport to configure TLS or authentication.
+	HTTPClient *http.Client
+}
+
+// NewHTTPClient returns an HTTPClient for the registry at baseURL.
+func NewHTTPClient(baseURL string) *HTTPClient {
+	return &HTTPClient{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type schemaPayload struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+	ID         int    `json:"id,omitempty"`
+}
+
+type errorPayload struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register implements Client.
+func (c *HTTPClient) Register(subject string, schema Schema) (int, error) {
+	req := schemaPayload{Schema: schema.Schema}
+	if schema.Type != TypeAvro {
+		req.SchemaType = schema.Type
+	}
+
+	var res schemaPayload
+	if err := c.do(http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", req, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// SchemaByID implements Client.
+func (c *HTTPClient) SchemaByID(id int) (Schema, error) {
+	var res schemaPayload
+	if err := c.do(http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &res); err != nil {
+		return Schema{}, err
+	}
+	if res.SchemaType == "" {
+		res.SchemaType = TypeAvro
+	}
+	return Schema{Type: res.SchemaType, Schema: res.Schema}, nil
+}
+
+func (c *HTTPClient) do(method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if in != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var e errorPayload
+		_ = json.Unmarshal(data, &e)
+		if res.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrSchemaNotFound, e.Message)
+		}
+		return fmt.Errorf("schemaregistry: %s %s failed with status %d: %s", method, path, res.StatusCode, e.Message)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,48 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/subjects/my_topic-value/versions", func(w http.ResponseWriter, r *http.Request) {
+		var req schemaPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if r.Method != http.MethodPost || req.SchemaType != TypeJSON || req.Schema != testJSONSchema {
+			t.Errorf("Unexpected registration %s %+v", r.Method, req)
+		}
+		_, _ = w.Write([]byte(`{"id":7}`))
+	})
+	mux.HandleFunc("/schemas/ids/7", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"schema":"{}","schemaType":"JSON"}`))
+	})
+	mux.HandleFunc("/schemas/ids/8", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL + "/")
+
+	id, err := client.Register("my_topic-value", Schema{Type: TypeJSON, Schema: testJSONSchema})
+	if err != nil || id != 7 {
+		t.Errorf("Unexpected registration result %d, err=%v", id, err)
+	}
+
+	schema, err := client.SchemaByID(7)
+	if err != nil || schema != (Schema{Type: TypeJSON, Schema: "{}"}) {
+		t.Errorf("Unexpected schema %+v, err=%v", schema, err)
+	}
+
+	if _, err := client.SchemaByID(8); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("Expected ErrSchemaNotFound, got %v", err)
+	}
+}
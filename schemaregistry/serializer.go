@@ -0,0 +1,125 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// Serializer turns values into payloads in the wire format, registering their
+// schema as needed.
+type Serializer interface {
+	// Serialize returns v serialized for the key or value of a message
+	// produced to topic.
+	Serialize(topic string, v interface{}) ([]byte, error)
+}
+
+// Deserializer parses payloads in the wire format.
+type Deserializer interface {
+	// Deserialize parses the key or value of a message consumed from topic
+	// into v.
+	Deserialize(topic string, data []byte, v interface{}) error
+}
+
+// NewEncoder returns the serialization of v as a sarama.Encoder, to be used
+// as the Key or Value of a sarama.ProducerMessage for topic.
+func NewEncoder(s Serializer, topic string, v interface{}) (sarama.Encoder, error) {
+	data, err := s.Serialize(topic, v)
+	if err != nil {
+		return nil, err
+	}
+	return sarama.ByteEncoder(data), nil
+}
+
+// DecodeValue parses the value of msg into v with d.
+func DecodeValue(d Deserializer, msg *sarama.ConsumerMessage, v interface{}) error {
+	return d.Deserialize(msg.Topic, msg.Value, v)
+}
+
+// DecodeKey parses the key of msg into v with d.
+func DecodeKey(d Deserializer, msg *sarama.ConsumerMessage, v interface{}) error {
+	return d.Deserialize(msg.Topic, msg.Key, v)
+}
+
+// JSONSerializer is a Serializer encoding values with encoding/json, described
+// by a JSON Schema. Values are not validated against the schema, which is up
+// to the Validate hook if required.
+type JSONSerializer struct {
+	// Client registers the schema. Wrap it with NewCachedClient to avoid a
+	// request to the registry for each message.
+	Client Client
+	// Schema is the JSON Schema document describing the values.
+	Schema string
+	// IsKey tells whether the serializer is used for message keys rather
+	// than values, which determines the subject of the schema.
+	IsKey bool
+	// SubjectNameStrategy returns the subject of the schema for a topic;
+	// TopicNameStrategy is used if nil.
+	SubjectNameStrategy func(topic string, isKey bool) string
+	// Validate, if set, is called with the schema and encoded value before
+	// framing it, and fails the serialization if it returns an error.
+	Validate func(schema string, data []byte) error
+}
+
+// NewJSONSerializer returns a JSONSerializer for message values described by
+// schema, which is registered with client.
+func NewJSONSerializer(client Client, schema string) *JSONSerializer {
+	return &JSONSerializer{Client: client, Schema: schema}
+}
+
+// Serialize implements Serializer.
+func (s *JSONSerializer) Serialize(topic string, v interface{}) ([]byte, error) {
+	strategy := s.SubjectNameStrategy
+	if strategy == nil {
+		strategy = TopicNameStrategy
+	}
+
+	id, err := s.Client.Register(strategy(topic, s.IsKey), Schema{Type: TypeJSON, Schema: s.Schema})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if s.Validate != nil {
+		if err := s.Validate(s.Schema, payload); err != nil {
+			return nil, err
+		}
+	}
+	return Encode(id, payload), nil
+}
+
+// JSONDeserializer is a Deserializer decoding values with encoding/json,
+// checking that they were serialized with a JSON Schema.
+type JSONDeserializer struct {
+	// Client resolves schema IDs. Wrap it with NewCachedClient to avoid a
+	// request to the registry for each message.
+	Client Client
+}
+
+// NewJSONDeserializer returns a JSONDeserializer resolving schema IDs with
+// client.
+func NewJSONDeserializer(client Client) *JSONDeserializer {
+	return &JSONDeserializer{Client: client}
+}
+
+// Deserialize implements Deserializer.
+func (d *JSONDeserializer) Deserialize(topic string, data []byte, v interface{}) error {
+	id, payload, err := Decode(data)
+	if err != nil {
+		return err
+	}
+
+	schema, err := d.Client.SchemaByID(id)
+	if err != nil {
+		return err
+	}
+	if schema.Type != TypeJSON {
+		return fmt.Errorf("schemaregistry: schema %d of %s data is of type %s, not %s", id, topic, schema.Type, TypeJSON)
+	}
+
+	return json.Unmarshal(payload, v)
+}
@@ -0,0 +1,82 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+const testJSONSchema = `{"type":"object","properties":{"name":{"type":"string"}}}`
+
+type testRecord struct {
+	Name string `json:"name"`
+}
+
+func TestWireFormat(t *testing.T) {
+	data := Encode(258, []byte("payload"))
+	if !bytes.Equal(data[:5], []byte{0, 0, 0, 1, 2}) {
+		t.Errorf("Unexpected framing %v", data[:5])
+	}
+
+	id, payload, err := Decode(data)
+	if err != nil || id != 258 || string(payload) != "payload" {
+		t.Errorf("Unexpected decoding %d %q %v", id, payload, err)
+	}
+
+	for _, invalid := range [][]byte{nil, {0, 0, 0}, {1, 0, 0, 0, 1, 'x'}} {
+		if _, _, err := Decode(invalid); err != ErrInvalidWireFormat {
+			t.Errorf("Expected ErrInvalidWireFormat decoding %v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestJSONSerializerRoundTrip(t *testing.T) {
+	registry := NewMemoryRegistry()
+	serializer := NewJSONSerializer(NewCachedClient(registry), testJSONSchema)
+
+	value, err := NewEncoder(serializer, "my_topic", testRecord{Name: "sarama"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := value.Encode()
+
+	if versions := registry.Versions("my_topic-value"); len(versions) != 1 {
+		t.Fatalf("Expected the schema to be registered under my_topic-value, got %v", versions)
+	}
+	if id, _, _ := Decode(data); id != registry.Versions("my_topic-value")[0] {
+		t.Errorf("Expected the payload to reference schema %d, got %d", registry.Versions("my_topic-value")[0], id)
+	}
+
+	var decoded testRecord
+	msg := &sarama.ConsumerMessage{Topic: "my_topic", Value: data}
+	if err := DecodeValue(NewJSONDeserializer(registry), msg, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name != "sarama" {
+		t.Errorf("Unexpected decoded value %+v", decoded)
+	}
+}
+
+func TestJSONSerializerKeySubject(t *testing.T) {
+	registry := NewMemoryRegistry()
+	serializer := NewJSONSerializer(registry, testJSONSchema)
+	serializer.IsKey = true
+
+	if _, err := serializer.Serialize("my_topic", testRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(registry.Versions("my_topic-key")) != 1 || len(registry.Versions("my_topic-value")) != 0 {
+		t.Error("Expected the schema to be registered under my_topic-key only")
+	}
+}
+
+func TestJSONDeserializerRejectsOtherSchemaTypes(t *testing.T) {
+	registry := NewMemoryRegistry()
+	id, _ := registry.Register("my_topic-value", Schema{Type: TypeAvro, Schema: `"string"`})
+
+	var v interface{}
+	if err := NewJSONDeserializer(registry).Deserialize("my_topic", Encode(id, []byte(`"x"`)), &v); err == nil {
+		t.Error("Expected an error deserializing data with an Avro schema")
+	}
+}
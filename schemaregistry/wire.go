@@ -0,0 +1,49 @@
+/*
+Package schemaregistry implements the Confluent Schema Registry wire format for
+the keys and values of Kafka messages, so that they can be exchanged with other
+clients using a schema registry.
+
+A serialized payload is prefixed with a magic byte (0) and the 4 byte big
+endian ID under which its schema is registered:
+
+	| 0x00 | schema ID (4 bytes) | payload |
+
+Serializers register their schema with a Client, which may be an HTTP client
+for a registry server (NewHTTPClient) or an in-memory registry for tests
+(NewMemoryRegistry), and wrap the payload in that framing. Deserializers parse
+the framing back and resolve the schema ID with the same Client. Wrap a Client
+with NewCachedClient to avoid querying the registry for every message.
+*/
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MagicByte is the first byte of the payloads in the wire format.
+const MagicByte byte = 0
+
+const headerLength = 5
+
+// ErrInvalidWireFormat is returned when decoding data which does not start
+// with the magic byte and a schema ID.
+var ErrInvalidWireFormat = errors.New("schemaregistry: data is not in the schema registry wire format")
+
+// Encode returns payload framed with the magic byte and schemaID.
+func Encode(schemaID int, payload []byte) []byte {
+	buf := make([]byte, headerLength+len(payload))
+	buf[0] = MagicByte
+	binary.BigEndian.PutUint32(buf[1:], uint32(schemaID))
+	copy(buf[headerLength:], payload)
+	return buf
+}
+
+// Decode parses the framing of data, returning the ID of the schema of the
+// payload and the payload itself, which shares the memory of data.
+func Decode(data []byte) (schemaID int, payload []byte, err error) {
+	if len(data) < headerLength || data[0] != MagicByte {
+		return 0, nil, ErrInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:])), data[headerLength:], nil
+}
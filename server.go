@@ -0,0 +1,225 @@
+package sarama
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// ProtocolBody is implemented by the requests and responses of the Kafka
+// protocol, such as *MetadataRequest and *MetadataResponse. A ServerHandler
+// receives the former and returns the latter; use a type switch to tell them
+// apart.
+type ProtocolBody interface {
+	protocolBody
+}
+
+// Request is a Kafka request received by a Server, along with its header.
+type Request struct {
+	// APIKey and APIVersion identify the type and version of Body.
+	APIKey     int16
+	APIVersion int16
+	// CorrelationID is echoed back by the Server in the response header.
+	CorrelationID int32
+	ClientID      string
+	// RemoteAddr is the address of the client which sent the request.
+	RemoteAddr net.Addr
+	// Body is the decoded request, for example a *MetadataRequest.
+	Body ProtocolBody
+}
+
+// ServerHandler responds to the requests received by a Server.
+//
+// Requests on a single connection are handled one at a time, in the order in
+// which they were received, and their responses are written in that same
+// order as required by the Kafka protocol. The response must be of the version
+// given by Request.APIVersion, which usually means setting its Version field.
+// A nil response sends nothing back, as expected for a ProduceRequest with
+// RequiredAcks set to NoResponse. An error closes the connection.
+type ServerHandler interface {
+	ServeKafka(req *Request) (ProtocolBody, error)
+}
+
+// ServerHandlerFunc is an adapter allowing the use of an ordinary function as
+// a ServerHandler.
+type ServerHandlerFunc func(req *Request) (ProtocolBody, error)
+
+// ServeKafka calls f(req).
+func (f ServerHandlerFunc) ServeKafka(req *Request) (ProtocolBody, error) {
+	return f(req)
+}
+
+// Server accepts connections speaking the Kafka protocol, decodes the requests
+// received on them and passes them to a ServerHandler, then encodes and writes
+// back the responses. It only deals with the framing and encoding of the
+// protocol: it is up to the handler to implement the behaviour of a broker,
+// which makes it suitable for proxies and protocol-level test doubles.
+//
+// Authentication mechanisms exchanging raw tokens outside of Kafka requests,
+// such as SASL/GSSAPI or SASL handshake v0, are not supported.
+type Server struct {
+	// Handler responds to the requests received by the server.
+	Handler ServerHandler
+	// Logger is used to log connection errors, see Config.Logger. If nil,
+	// messages are written to the global Logger.
+	Logger LevelledLogger
+
+	lock      sync.Mutex
+	listeners map[net.Listener]none
+	conns     map[net.Conn]none
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer returns a Server passing the requests it receives to handler.
+func NewServer(handler ServerHandler) *Server {
+	return &Server{Handler: handler}
+}
+
+// Serve accepts connections on l, serving each of them in its own goroutine,
+// until l fails or the server is closed. It always returns a non-nil error,
+// which is ErrServerClosed after a call to Close.
+func (s *Server) Serve(l net.Listener) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]none)
+	}
+	s.listeners[l] = none{}
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.listeners, l)
+		s.lock.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		go withRecover(func() {
+			if err := s.ServeConn(conn); err != nil && err != io.EOF && !s.isClosed() {
+				s.logger().Warnf("server/%s connection closed: %v\n", conn.RemoteAddr(), err)
+			}
+		})
+	}
+}
+
+// ServeConn serves the requests received on conn until it is closed, the
+// handler returns an error or the server is closed. It closes conn before
+// returning, and returns io.EOF if the client closed the connection cleanly.
+func (s *Server) ServeConn(conn net.Conn) error {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return ErrServerClosed
+	}
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]none)
+	}
+	s.conns[conn] = none{}
+	s.wg.Add(1)
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		req, err := DecodeRequest(r)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		req.RemoteAddr = conn.RemoteAddr()
+
+		res, err := s.Handler.ServeKafka(req)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			continue
+		}
+
+		encodedRes, err := encode(res, nil)
+		if err != nil {
+			return err
+		}
+		header := encodeResponseHeader(res.headerVersion(), req.CorrelationID, uint32(len(encodedRes)))
+		if _, err := conn.Write(append(header, encodedRes...)); err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops the listeners passed to Serve and closes the connections being
+// served, then waits for the handlers in progress to return.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	var firstErr error
+	for l := range s.listeners {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	return firstErr
+}
+
+func (s *Server) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closed
+}
+
+func (s *Server) logger() fieldLogger {
+	return fieldLogger{l: s.Logger}
+}
+
+// encodeResponseHeader returns the length prefix and header of a response
+// whose encoded body is payloadLength bytes long.
+func encodeResponseHeader(headerVersion int16, correlationID int32, payloadLength uint32) []byte {
+	headerLength := uint32(8)
+
+	if headerVersion >= 1 {
+		headerLength = 9
+	}
+
+	resHeader := make([]byte, headerLength)
+	binary.BigEndian.PutUint32(resHeader, payloadLength+headerLength-4)
+	binary.BigEndian.PutUint32(resHeader[4:], uint32(correlationID))
+
+	if headerVersion >= 1 {
+		binary.PutUvarint(resHeader[8:], 0)
+	}
+
+	return resHeader
+}
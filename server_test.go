@@ -0,0 +1,83 @@
+package sarama
+
+import (
+	"net"
+	"testing"
+)
+
+func TestServerServesClient(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clientIDs []string
+	server := NewServer(ServerHandlerFunc(func(req *Request) (ProtocolBody, error) {
+		clientIDs = append(clientIDs, req.ClientID)
+		switch body := req.Body.(type) {
+		case *MetadataRequest:
+			res := &MetadataResponse{Version: body.Version}
+			res.AddBroker(l.Addr().String(), 1)
+			res.AddTopicPartition("my_topic", 0, 1, nil, nil, nil, ErrNoError)
+			return res, nil
+		default:
+			t.Errorf("Unexpected request %T", body)
+			return nil, nil
+		}
+	}))
+
+	served := make(chan error)
+	go func() {
+		served <- server.Serve(l)
+	}()
+
+	config := NewTestConfig()
+	config.ClientID = "server_test"
+	client, err := NewClient([]string{l.Addr().String()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partitions, err := client.Partitions("my_topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partitions) != 1 || partitions[0] != 0 {
+		t.Errorf("Expected partition 0, got %v", partitions)
+	}
+	safeClose(t, client)
+
+	if err := server.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+	if len(clientIDs) == 0 || clientIDs[0] != "server_test" {
+		t.Errorf("Unexpected client ids %v", clientIDs)
+	}
+}
+
+func TestServerHandlerErrorClosesConnection(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(ServerHandlerFunc(func(req *Request) (ProtocolBody, error) {
+		return nil, ErrOutOfBrokers
+	}))
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer safeClose(t, server)
+
+	broker := NewBroker(l.Addr().String())
+	if err := broker.Open(NewTestConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err == nil {
+		t.Error("Expected an error once the server closed the connection")
+	}
+	safeClose(t, broker)
+}
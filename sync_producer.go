@@ -1,6 +1,6 @@
 package sarama
 
-import "sync"
+import "time"
 
 // SyncProducer publishes Kafka messages, blocking until they have been acknowledged. It routes messages to the correct
 // broker, refreshing metadata as appropriate, and parses responses for errors. You must call Close() on a producer
@@ -10,8 +10,8 @@ import "sync"
 // durability guarantee provided when a message is acknowledged depend on the configured value of `Producer.RequiredAcks`.
 // There are configurations where a message acknowledged by the SyncProducer can still sometimes be lost.
 //
-// For implementation reasons, the SyncProducer requires `Producer.Return.Errors` and `Producer.Return.Successes` to
-// be set to true in its configuration.
+// The results are handed back to the caller directly, so the `Producer.Return.Errors` and `Producer.Return.Successes`
+// settings have no effect on the SyncProducer.
 type SyncProducer interface {
 
 	// SendMessage produces a given message, and returns only when it either has
@@ -25,29 +25,38 @@ type SyncProducer interface {
 	// SendMessages will return an error.
 	SendMessages(msgs []*ProducerMessage) error
 
+	// SendMessagesWithResults produces a given set of messages, and returns
+	// only when all messages in the set have either succeeded or failed. Unlike
+	// SendMessages, it returns the outcome of each message, in the same order
+	// as msgs, so that partial failures can be reported precisely.
+	SendMessagesWithResults(msgs []*ProducerMessage) []ProducerResult
+
 	// Close shuts down the producer and waits for any buffered messages to be
 	// flushed. You must call this function before a producer object passes out of
 	// scope, as it may otherwise leak memory. You must call this before calling
-	// Close on the underlying client.
+	// Close on the underlying client. It always returns nil, as the outcome of
+	// every message is returned by the method that sent it.
 	Close() error
 }
 
+// ProducerResult is the outcome of producing a single message with
+// SyncProducer.SendMessagesWithResults.
+type ProducerResult struct {
+	// Partition and Offset the message was written to, or -1 if it failed.
+	Partition int32
+	Offset    int64
+	// Timestamp of the message, as documented on ProducerMessage.Timestamp.
+	Timestamp time.Time
+	// Err is nil if the message was produced successfully.
+	Err error
+}
+
 type syncProducer struct {
 	producer *asyncProducer
-	wg       sync.WaitGroup
 }
 
 // NewSyncProducer creates a new SyncProducer using the given broker addresses and configuration.
 func NewSyncProducer(addrs []string, config *Config) (SyncProducer, error) {
-	if config == nil {
-		config = NewConfig()
-		config.Producer.Return.Successes = true
-	}
-
-	if err := verifyProducerConfig(config); err != nil {
-		return nil, err
-	}
-
 	p, err := NewAsyncProducer(addrs, config)
 	if err != nil {
 		return nil, err
@@ -58,10 +67,6 @@ func NewSyncProducer(addrs []string, config *Config) (SyncProducer, error) {
 // NewSyncProducerFromClient creates a new SyncProducer using the given client. It is still
 // necessary to call Close() on the underlying client when shutting down this producer.
 func NewSyncProducerFromClient(client Client) (SyncProducer, error) {
-	if err := verifyProducerConfig(client.Config()); err != nil {
-		return nil, err
-	}
-
 	p, err := NewAsyncProducerFromClient(client)
 	if err != nil {
 		return nil, err
@@ -70,23 +75,7 @@ func NewSyncProducerFromClient(client Client) (SyncProducer, error) {
 }
 
 func newSyncProducerFromAsyncProducer(p *asyncProducer) *syncProducer {
-	sp := &syncProducer{producer: p}
-
-	sp.wg.Add(2)
-	go withRecover(sp.handleSuccesses)
-	go withRecover(sp.handleErrors)
-
-	return sp
-}
-
-func verifyProducerConfig(config *Config) error {
-	if !config.Producer.Return.Errors {
-		return ConfigurationError("Producer.Return.Errors must be true to be used in a SyncProducer")
-	}
-	if !config.Producer.Return.Successes {
-		return ConfigurationError("Producer.Return.Successes must be true to be used in a SyncProducer")
-	}
-	return nil
+	return &syncProducer{producer: p}
 }
 
 func (sp *syncProducer) SendMessage(msg *ProducerMessage) (partition int32, offset int64, err error) {
@@ -102,6 +91,20 @@ func (sp *syncProducer) SendMessage(msg *ProducerMessage) (partition int32, offs
 }
 
 func (sp *syncProducer) SendMessages(msgs []*ProducerMessage) error {
+	var errors ProducerErrors
+	for i, result := range sp.SendMessagesWithResults(msgs) {
+		if result.Err != nil {
+			errors = append(errors, &ProducerError{Msg: msgs[i], Err: result.Err})
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func (sp *syncProducer) SendMessagesWithResults(msgs []*ProducerMessage) []ProducerResult {
 	expectations := make(chan chan *ProducerError, len(msgs))
 	go func() {
 		for _, msg := range msgs {
@@ -113,37 +116,20 @@ func (sp *syncProducer) SendMessages(msgs []*ProducerMessage) error {
 		close(expectations)
 	}()
 
-	var errors ProducerErrors
+	results := make([]ProducerResult, 0, len(msgs))
 	for expectation := range expectations {
+		msg := msgs[len(results)]
 		if err := <-expectation; err != nil {
-			errors = append(errors, err)
+			results = append(results, ProducerResult{Partition: -1, Offset: -1, Err: err.Err})
+		} else {
+			results = append(results, ProducerResult{Partition: msg.Partition, Offset: msg.Offset, Timestamp: msg.Timestamp})
 		}
 	}
-
-	if len(errors) > 0 {
-		return errors
-	}
-	return nil
-}
-
-func (sp *syncProducer) handleSuccesses() {
-	defer sp.wg.Done()
-	for msg := range sp.producer.Successes() {
-		expectation := msg.expectation
-		expectation <- nil
-	}
-}
-
-func (sp *syncProducer) handleErrors() {
-	defer sp.wg.Done()
-	for err := range sp.producer.Errors() {
-		expectation := err.Msg.expectation
-		expectation <- err
-	}
+	return results
 }
 
 func (sp *syncProducer) Close() error {
-	sp.producer.AsyncClose()
-	sp.wg.Wait()
+	// every message has an expectation, so no error is left to report here
+	_ = sp.producer.Close()
 	return nil
 }
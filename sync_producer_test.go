@@ -104,6 +104,86 @@ func TestSyncProducerBatch(t *testing.T) {
 	seedBroker.Close()
 }
 
+func TestSyncProducerBatchWithResults(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("my_topic", 1, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodResponse := new(ProduceResponse)
+	prodResponse.AddTopicPartition("my_topic", 0, ErrNoError)
+	prodResponse.Blocks["my_topic"][0].Offset = 42
+	prodResponse.AddTopicPartition("my_topic", 1, ErrMessageSizeTooLarge)
+	leader.Returns(prodResponse)
+
+	// neither Return.Successes nor Return.Errors are required
+	config := NewTestConfig()
+	config.Producer.Flush.Messages = 2
+	config.Producer.Return.Errors = false
+	config.Producer.Partitioner = NewManualPartitioner
+	producer, err := NewSyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := []*ProducerMessage{
+		{Topic: "my_topic", Value: StringEncoder(TestMessage), Partition: 0},
+		{Topic: "my_topic", Value: StringEncoder(TestMessage), Partition: 1},
+	}
+	results := producer.SendMessagesWithResults(msgs)
+
+	if len(results) != len(msgs) {
+		t.Fatalf("Expected %d results, got %d", len(msgs), len(results))
+	}
+	if results[0].Err != nil || results[0].Partition != 0 || results[0].Offset != 42 {
+		t.Errorf("Unexpected result for the first message: %+v", results[0])
+	}
+	if results[1].Err != ErrMessageSizeTooLarge || results[1].Partition != -1 || results[1].Offset != -1 {
+		t.Errorf("Unexpected result for the second message: %+v", results[1])
+	}
+
+	safeClose(t, producer)
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestSyncProducerCloseAfterFailure(t *testing.T) {
+	seedBroker := NewMockBroker(t, 1)
+	leader := NewMockBroker(t, 2)
+
+	metadataResponse := new(MetadataResponse)
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodResponse := new(ProduceResponse)
+	prodResponse.AddTopicPartition("my_topic", 0, ErrMessageSizeTooLarge)
+	leader.Returns(prodResponse)
+
+	// the failure is returned by SendMessage only, whatever Return.Errors
+	config := NewTestConfig()
+	config.Producer.Return.Errors = true
+	config.Producer.Return.Successes = false
+	producer, err := NewSyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := producer.SendMessage(&ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}); err != ErrMessageSizeTooLarge {
+		t.Errorf("Expected ErrMessageSizeTooLarge, got %v", err)
+	}
+	if err := producer.Close(); err != nil {
+		t.Errorf("Expected Close to return nil, got %v", err)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
 func TestConcurrentSyncProducer(t *testing.T) {
 	seedBroker := NewMockBroker(t, 1)
 	leader := NewMockBroker(t, 2)
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+var (
+	requests  = flag.String("requests", "", "REQUIRED: the file containing the bytes sent by the client to the broker")
+	responses = flag.String("responses", "", "The file containing the bytes sent by the broker to the client")
+	pretty    = flag.Bool("pretty", false, "Whether to indent the JSON output")
+)
+
+type frame struct {
+	Direction     string      `json:"direction"`
+	APIKey        int16       `json:"api_key"`
+	APIVersion    int16       `json:"api_version"`
+	CorrelationID int32       `json:"correlation_id"`
+	ClientID      string      `json:"client_id,omitempty"`
+	Type          string      `json:"type"`
+	Body          interface{} `json:"body"`
+}
+
+func main() {
+	flag.Parse()
+
+	if *requests == "" {
+		printUsageErrorAndExit("-requests is required")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	if *pretty {
+		encoder.SetIndent("", "  ")
+	}
+
+	decoder := sarama.NewStreamDecoder()
+
+	err := decodeFile(*requests, requestPrinter(decoder, encoder))
+	if err != nil {
+		printErrorAndExit(65, "Failed to decode requests: %s", err)
+	}
+
+	if *responses == "" {
+		return
+	}
+
+	err = decodeFile(*responses, responsePrinter(decoder, encoder, os.Stderr))
+	if err != nil {
+		printErrorAndExit(65, "Failed to decode responses: %s", err)
+	}
+
+	for _, req := range decoder.Pending() {
+		fmt.Fprintf(os.Stderr, "WARNING: no response to %s with correlation ID %d\n", typeName(req.Body), req.CorrelationID)
+	}
+}
+
+// requestPrinter returns a function decoding a single request with decoder
+// and printing it with encoder.
+func requestPrinter(decoder *sarama.StreamDecoder, encoder *json.Encoder) func(r io.Reader) error {
+	return func(r io.Reader) error {
+		req, err := decoder.DecodeRequest(r)
+		if err != nil {
+			return err
+		}
+		return encoder.Encode(frame{
+			Direction:     "request",
+			APIKey:        req.APIKey,
+			APIVersion:    req.APIVersion,
+			CorrelationID: req.CorrelationID,
+			ClientID:      req.ClientID,
+			Type:          typeName(req.Body),
+			Body:          req.Body,
+		})
+	}
+}
+
+// responsePrinter returns a function decoding a single response with decoder
+// and printing it with encoder. Responses to unknown requests are skipped
+// with a warning.
+func responsePrinter(decoder *sarama.StreamDecoder, encoder *json.Encoder, warnings io.Writer) func(r io.Reader) error {
+	return func(r io.Reader) error {
+		res, req, err := decoder.DecodeResponse(r)
+		if err != nil {
+			if req == nil {
+				// the response was skipped, carry on with the next one
+				fmt.Fprintf(warnings, "WARNING: %s\n", err)
+				return nil
+			}
+			return err
+		}
+		return encoder.Encode(frame{
+			Direction:     "response",
+			APIKey:        res.APIKey,
+			APIVersion:    res.APIVersion,
+			CorrelationID: res.CorrelationID,
+			ClientID:      req.ClientID,
+			Type:          typeName(res.Body),
+			Body:          res.Body,
+		})
+	}
+}
+
+// decodeFile calls decode until the file at path is fully consumed.
+func decodeFile(path string, decode func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return decodeAll(bufio.NewReader(f), decode)
+}
+
+// decodeAll calls decode until r is fully consumed.
+func decodeAll(r *bufio.Reader, decode func(r io.Reader) error) error {
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return nil
+		}
+		if err := decode(r); err != nil {
+			return err
+		}
+	}
+}
+
+func typeName(body sarama.ProtocolBody) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", body), "*sarama.")
+}
+
+func printErrorAndExit(code int, format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	os.Exit(code)
+}
+
+func printUsageErrorAndExit(format string, values ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", fmt.Sprintf(format, values...))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Available command line options:")
+	flag.PrintDefaults()
+	os.Exit(64)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// recordingListener records the bytes read from and written to the
+// connections it accepts, that is the requests and the responses.
+type recordingListener struct {
+	net.Listener
+	lock      sync.Mutex
+	requests  bytes.Buffer
+	responses bytes.Buffer
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, l: l}, nil
+}
+
+type recordingConn struct {
+	net.Conn
+	l *recordingListener
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.l.lock.Lock()
+	c.l.requests.Write(b[:n])
+	c.l.lock.Unlock()
+	return n, err
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.l.lock.Lock()
+	c.l.responses.Write(b[:n])
+	c.l.lock.Unlock()
+	return n, err
+}
+
+// record returns the traffic of a connection sending reqs to a server
+// answering them with handler.
+func record(t *testing.T, handler sarama.ServerHandlerFunc, reqs func(*sarama.Broker) error) (requests, responses []byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl := &recordingListener{Listener: l}
+	server := sarama.NewServer(handler)
+	go func() { _ = server.Serve(rl) }()
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	broker := sarama.NewBroker(l.Addr().String())
+	if err := broker.Open(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := reqs(broker); err != nil {
+		t.Fatal(err)
+	}
+	_ = broker.Close()
+	if err := server.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	return rl.requests.Bytes(), rl.responses.Bytes()
+}
+
+// decodeFrames decodes the recorded traffic as the tool does, and returns the
+// printed frames.
+func decodeFrames(t *testing.T, requests, responses []byte) []map[string]interface{} {
+	var out bytes.Buffer
+	decoder := sarama.NewStreamDecoder()
+	encoder := json.NewEncoder(&out)
+	if err := decodeAll(bufio.NewReader(bytes.NewReader(requests)), requestPrinter(decoder, encoder)); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeAll(bufio.NewReader(bytes.NewReader(responses)), responsePrinter(decoder, encoder, ioutil.Discard)); err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []map[string]interface{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var frame map[string]interface{}
+		if err := dec.Decode(&frame); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// lookup walks v along path, failing the test if any step is missing.
+func lookup(t *testing.T, v interface{}, path ...interface{}) interface{} {
+	t.Helper()
+	for _, step := range path {
+		switch s := step.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok || m[s] == nil {
+				t.Fatalf("Missing %q in %v", s, v)
+			}
+			v = m[s]
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || len(a) <= s {
+				t.Fatalf("Missing index %d in %v", s, v)
+			}
+			v = a[s]
+		}
+	}
+	return v
+}
+
+func TestDecodeProduceAndFetch(t *testing.T) {
+	value := base64.StdEncoding.EncodeToString([]byte("hello"))
+	now := time.Now().Truncate(time.Millisecond)
+
+	handler := func(req *sarama.Request) (sarama.ProtocolBody, error) {
+		switch req.Body.(type) {
+		case *sarama.ProduceRequest:
+			res := &sarama.ProduceResponse{Version: req.APIVersion}
+			res.AddTopicPartition("my_topic", 0, sarama.ErrNoError)
+			res.Blocks["my_topic"][0].Offset = 42
+			return res, nil
+		case *sarama.FetchRequest:
+			res := &sarama.FetchResponse{Version: req.APIVersion}
+			res.AddRecordWithTimestamp("my_topic", 0, nil, sarama.StringEncoder("hello"), 42, now)
+			return res, nil
+		}
+		t.Errorf("Unexpected request %T", req.Body)
+		return nil, nil
+	}
+
+	requests, responses := record(t, handler, func(b *sarama.Broker) error {
+		produce := &sarama.ProduceRequest{Version: 3, RequiredAcks: sarama.WaitForLocal, Timeout: 1000}
+		produce.AddBatch("my_topic", 0, &sarama.RecordBatch{
+			Version:        2,
+			ProducerID:     -1,
+			FirstTimestamp: now,
+			MaxTimestamp:   now,
+			Records:        []*sarama.Record{{Value: []byte("hello")}},
+		})
+		if _, err := b.Produce(produce); err != nil {
+			return err
+		}
+
+		fetch := &sarama.FetchRequest{Version: 4, MaxWaitTime: 100, MinBytes: 1}
+		fetch.AddBlock("my_topic", 0, 42, 1024)
+		_, err := b.Fetch(fetch)
+		return err
+	})
+
+	frames := decodeFrames(t, requests, responses)
+	if len(frames) != 4 {
+		t.Fatalf("Expected 4 frames, got %d", len(frames))
+	}
+
+	if v := lookup(t, frames[0], "body", "Records", "my_topic", "0", "RecordBatch", "Records", 0, "Value"); v != value {
+		t.Errorf("Expected the produced value %q, got %v", value, v)
+	}
+	if v := lookup(t, frames[1], "body", "Blocks", "my_topic", "0", "FetchOffset"); v != 42.0 {
+		t.Errorf("Expected fetch offset 42, got %v", v)
+	}
+	if v := lookup(t, frames[1], "body", "Blocks", "my_topic", "0", "MaxBytes"); v != 1024.0 {
+		t.Errorf("Expected max bytes 1024, got %v", v)
+	}
+	if typ := frames[2]["type"]; typ != "ProduceResponse" {
+		t.Errorf("Expected the ProduceResponse, got %v", typ)
+	}
+	if v := lookup(t, frames[2], "body", "Blocks", "my_topic", "0", "Offset"); v != 42.0 {
+		t.Errorf("Expected produced offset 42, got %v", v)
+	}
+	if v := lookup(t, frames[3], "body", "Blocks", "my_topic", "0", "RecordsSet", 0, "RecordBatch", "Records", 0, "Value"); v != value {
+		t.Errorf("Expected the fetched value %q, got %v", value, v)
+	}
+}